@@ -0,0 +1,154 @@
+package fs
+
+import (
+	"hash"
+	"io"
+
+	"github.com/sbreitf1/fs/path"
+
+	"github.com/sbreitf1/errors"
+)
+
+// CopyFileContent copies the content of src on fsSrc to dst on fsDst using the given copy options. Metadata is not copied. Copies on the same file system use the native copy function of the driver if available.
+//
+// This is the file copy implementation used by FileSystem and the interop package.
+func CopyFileContent(fsSrc *FileSystem, src string, fsDst *FileSystem, dst string, options *CopyOptions) errors.Error {
+	if options == nil {
+		options = &CopyOptions{}
+	}
+
+	// native copies always start from the beginning
+	if fsSrc == fsDst && !options.Resume {
+		if driver, ok := fsDst.nativeCopyDriver(); ok {
+			err := driver.CopyFile(src, dst)
+			if err == nil {
+				return verifyCopy(fsSrc, src, fsDst, dst, options)
+			}
+			if !errors.InstanceOf(err, ErrNotSupported) {
+				return err
+			}
+		}
+	}
+
+	reader, writer, offset, err := OpenCopyFiles(fsSrc, src, fsDst, dst, options)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	var sourceHash hash.Hash
+	var r io.Reader = reader
+	// resumed copies are verified by reading the whole source file again
+	if len(options.Verify) > 0 && offset == 0 {
+		sourceHash, err = options.Verify.New()
+		if err != nil {
+			writer.Close()
+			return err
+		}
+		r = io.TeeReader(reader, sourceHash)
+	}
+
+	if _, err := CopyBuffered(writer, r); err != nil {
+		writer.Close()
+		// keep typed errors of wrapping drivers
+		if e, ok := err.(errors.Error); ok {
+			return e
+		}
+		return Err.Msg("Failed to copy file").Make().Cause(err)
+	}
+	if err := fsDst.CloseWrittenFile(writer, dst); err != nil {
+		return err
+	}
+
+	if sourceHash != nil {
+		return fsDst.VerifyFile(dst, options.Verify, sourceHash.Sum(nil))
+	}
+	if offset > 0 {
+		return verifyCopy(fsSrc, src, fsDst, dst, options)
+	}
+	return nil
+}
+
+// CopyTree copies all files and directories contained in src on fsSrc to the existing directory dst on fsDst using the given copy options. Files are copied concurrently if options.Parallelism is greater than 1.
+//
+// This is the directory copy implementation used by FileSystem and the interop package.
+func CopyTree(fsSrc *FileSystem, src string, fsDst *FileSystem, dst string, options *CopyOptions) errors.Error {
+	if options == nil {
+		options = &CopyOptions{}
+	}
+
+	copyFile := func(src, dst string) errors.Error {
+		if err := CopyFileContent(fsSrc, src, fsDst, dst, options); err != nil {
+			return err
+		}
+		return CopyMetadata(fsSrc, src, fsDst, dst, options)
+	}
+
+	dirs := make([]copyTask, 0)
+	if options.Parallelism > 1 {
+		pool := NewCopyPool(options.Parallelism, copyFile)
+		err := copyTree(fsSrc, src, fsDst, dst, func(src, dst string) errors.Error {
+			pool.Submit(src, dst)
+			return nil
+		}, &dirs)
+		// always wait for the workers to finish before returning
+		poolErr := pool.Wait()
+		if err != nil {
+			return err
+		}
+		if poolErr != nil {
+			return poolErr
+		}
+	} else {
+		if err := copyTree(fsSrc, src, fsDst, dst, copyFile, &dirs); err != nil {
+			return err
+		}
+	}
+
+	// directories are modified while copying their content
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := CopyMetadata(fsSrc, dirs[i].src, fsDst, dirs[i].dst, options); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyTree creates all directories contained in src on fsDst and calls copyFile for every file. All created directories are appended to dirs.
+func copyTree(fsSrc *FileSystem, src string, fsDst *FileSystem, dst string, copyFile func(src, dst string) errors.Error, dirs *[]copyTask) errors.Error {
+	files, err := fsSrc.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		if f.IsDir() {
+			if err := fsDst.CreateDirectory(path.Join(dst, f.Name())); err != nil {
+				return err
+			}
+			*dirs = append(*dirs, copyTask{path.Join(src, f.Name()), path.Join(dst, f.Name())})
+			if err := copyTree(fsSrc, path.Join(src, f.Name()), fsDst, path.Join(dst, f.Name()), copyFile, dirs); err != nil {
+				return err
+			}
+		} else {
+			if err := copyFile(path.Join(src, f.Name()), path.Join(dst, f.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// verifyCopy compares dst with the content of src if verification is enabled.
+func verifyCopy(fsSrc *FileSystem, src string, fsDst *FileSystem, dst string, options *CopyOptions) errors.Error {
+	if len(options.Verify) == 0 {
+		return nil
+	}
+
+	checksum, err := fsSrc.HashContent(src, options.Verify)
+	if err != nil {
+		return err
+	}
+	return fsDst.VerifyFile(dst, options.Verify, checksum)
+}
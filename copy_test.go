@@ -0,0 +1,38 @@
+package fs
+
+import (
+	"testing"
+
+	"github.com/sbreitf1/errors"
+)
+
+func TestCopyTree(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		fsSrc := NewWithDriver(&LocalDriver{Root: tmpDir})
+		fsDst := NewWithDriver(&LocalDriver{Root: tmpDir})
+		errors.AssertNil(t, fsSrc.CreateDirectory("/src/sub"))
+		errors.AssertNil(t, fsSrc.WriteString("/src/a.txt", "a"))
+		errors.AssertNil(t, fsSrc.WriteString("/src/sub/b.txt", "b"))
+
+		t.Run("TestCopyTreeSequential", func(t *testing.T) {
+			errors.AssertNil(t, fsDst.CreateDirectory("/seq"))
+			errors.AssertNil(t, CopyTree(fsSrc, "/src", fsDst, "/seq", nil))
+			assertFileContent(t, fsDst, "/seq/a.txt", "a")
+			assertFileContent(t, fsDst, "/seq/sub/b.txt", "b")
+		})
+
+		t.Run("TestCopyTreeParallel", func(t *testing.T) {
+			errors.AssertNil(t, fsDst.CreateDirectory("/par"))
+			errors.AssertNil(t, CopyTree(fsSrc, "/src", fsDst, "/par", &CopyOptions{Parallelism: 4, Verify: HashSHA256}))
+			assertFileContent(t, fsDst, "/par/a.txt", "a")
+			assertFileContent(t, fsDst, "/par/sub/b.txt", "b")
+		})
+
+		t.Run("TestCopyFileContent", func(t *testing.T) {
+			errors.AssertNil(t, CopyFileContent(fsSrc, "/src/a.txt", fsDst, "/c.txt", nil))
+			assertFileContent(t, fsDst, "/c.txt", "a")
+			errors.Assert(t, ErrFileNotExists, CopyFileContent(fsSrc, "/src/missing.txt", fsDst, "/d.txt", nil))
+		})
+		return nil
+	}))
+}
@@ -0,0 +1,98 @@
+package fs
+
+import (
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/sbreitf1/errors"
+)
+
+const (
+	// DefaultCopyBufferSize denotes the size of buffers used to copy file content.
+	DefaultCopyBufferSize = 32 * 1024
+)
+
+var (
+	// ErrCopyFailed occurs when multiple files could not be copied by a parallel copy operation. The error of the first failed file in lexicographic order is attached as cause.
+	ErrCopyFailed = errors.New("Failed to copy %d files, first failed file is %q")
+)
+
+var copyBufferPool = sync.Pool{New: func() interface{} {
+	buf := make([]byte, DefaultCopyBufferSize)
+	return &buf
+}}
+
+// CopyBuffered copies all data from r to w using a buffer from a shared pool.
+func CopyBuffered(w io.Writer, r io.Reader) (int64, error) {
+	buf := copyBufferPool.Get().(*[]byte)
+	defer copyBufferPool.Put(buf)
+	return io.CopyBuffer(w, r, *buf)
+}
+
+// CopyPool copies files concurrently using a fixed number of workers. In contrast to sequential copy operations, all submitted files are processed even if some of them fail.
+type CopyPool struct {
+	copyFunc func(src, dst string) errors.Error
+	tasks    chan copyTask
+	wg       sync.WaitGroup
+	mutex    sync.Mutex
+	errs     []copyTaskError
+}
+
+type copyTask struct {
+	src, dst string
+}
+
+type copyTaskError struct {
+	src string
+	err errors.Error
+}
+
+// NewCopyPool starts parallelism workers that call copyFunc for all submitted files. Call Wait to release the workers.
+func NewCopyPool(parallelism int, copyFunc func(src, dst string) errors.Error) *CopyPool {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	pool := &CopyPool{copyFunc: copyFunc, tasks: make(chan copyTask, parallelism), errs: make([]copyTaskError, 0)}
+	pool.wg.Add(parallelism)
+	for i := 0; i < parallelism; i++ {
+		go pool.work()
+	}
+	return pool
+}
+
+func (p *CopyPool) work() {
+	defer p.wg.Done()
+
+	for task := range p.tasks {
+		if err := p.copyFunc(task.src, task.dst); err != nil {
+			p.mutex.Lock()
+			p.errs = append(p.errs, copyTaskError{task.src, err})
+			p.mutex.Unlock()
+		}
+	}
+}
+
+// Submit queues a file to be copied. It blocks while all workers are busy.
+func (p *CopyPool) Submit(src, dst string) {
+	p.tasks <- copyTask{src, dst}
+}
+
+// Wait blocks until all submitted files have been copied and stops the workers. The error of a single failed file is returned as is, multiple errors are reported as ErrCopyFailed independent of the processing order.
+func (p *CopyPool) Wait() errors.Error {
+	close(p.tasks)
+	p.wg.Wait()
+
+	if len(p.errs) == 0 {
+		return nil
+	}
+	if len(p.errs) == 1 {
+		return p.errs[0].err
+	}
+
+	sort.Slice(p.errs, func(i, j int) bool {
+		return p.errs[i].src < p.errs[j].src
+	})
+	return ErrCopyFailed.Args(len(p.errs), p.errs[0].src).Make().Cause(p.errs[0].err)
+}
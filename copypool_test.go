@@ -0,0 +1,72 @@
+package fs
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyParallel(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		fs := NewWithDriver(&LocalDriver{Root: tmpDir})
+		prepareParallelCopyDir(t, fs)
+
+		t.Run("TestCopyDir", func(t *testing.T) {
+			errors.AssertNil(t, fs.CopyDirWithOptions("/src", "/dst", &CopyOptions{Parallelism: 4}))
+			assertParallelCopyDir(t, fs, "/dst")
+		})
+
+		t.Run("TestCopyAll", func(t *testing.T) {
+			errors.AssertNil(t, fs.CreateDirectory("/all"))
+			errors.AssertNil(t, fs.CopyAllWithOptions("/src", "/all", &CopyOptions{Parallelism: 4}))
+			assertParallelCopyDir(t, fs, "/all")
+		})
+
+		t.Run("TestSourceNotExists", func(t *testing.T) {
+			errors.Assert(t, ErrDirectoryNotExists, fs.CopyDirWithOptions("/nonexisting", "/dst2", &CopyOptions{Parallelism: 4}))
+		})
+		return nil
+	}))
+}
+
+func TestCopyParallelErrors(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		fs := NewWithDriver(&LocalDriver{Root: tmpDir})
+		prepareParallelCopyDir(t, fs)
+
+		failingFS := NewWithDriver(&failingCloseDriver{&LocalDriver{Root: tmpDir}})
+		for i := 0; i < 5; i++ {
+			// errors are reported independent of processing order
+			err := failingFS.CopyDirWithOptions("/src", "/dst", &CopyOptions{Parallelism: 4})
+			errors.Assert(t, ErrCopyFailed, err)
+			assert.True(t, strings.Contains(err.Error(), `Failed to copy 22 files, first failed file is "/src/a/b/file0.txt"`), "Unexpected error message %q", err.Error())
+		}
+
+		errors.Assert(t, Err, failingFS.CopyFileWithOptions("/src/file0.txt", "/dst/file0.txt", &CopyOptions{Parallelism: 4}))
+		return nil
+	}))
+}
+
+func prepareParallelCopyDir(t *testing.T, fs *FileSystem) {
+	errors.AssertNil(t, fs.CreateDirectory("/src/a/b"))
+	errors.AssertNil(t, fs.CreateDirectory("/src/empty"))
+	for i := 0; i < 10; i++ {
+		errors.AssertNil(t, fs.WriteString(fmt.Sprintf("/src/file%d.txt", i), fmt.Sprintf("content %d", i)))
+		errors.AssertNil(t, fs.WriteString(fmt.Sprintf("/src/a/file%d.txt", i), fmt.Sprintf("a %d", i)))
+	}
+	errors.AssertNil(t, fs.WriteString("/src/a/b/file0.txt", "b 0"))
+	errors.AssertNil(t, fs.WriteString("/src/a/b/file1.txt", "b 1"))
+}
+
+func assertParallelCopyDir(t *testing.T, fs *FileSystem, dir string) {
+	for i := 0; i < 10; i++ {
+		assertFileContent(t, fs, fmt.Sprintf("%s/file%d.txt", dir, i), fmt.Sprintf("content %d", i))
+		assertFileContent(t, fs, fmt.Sprintf("%s/a/file%d.txt", dir, i), fmt.Sprintf("a %d", i))
+	}
+	assertFileContent(t, fs, dir+"/a/b/file0.txt", "b 0")
+	assertFileContent(t, fs, dir+"/a/b/file1.txt", "b 1")
+	assertIsDir(t, fs, dir+"/empty")
+}
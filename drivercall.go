@@ -0,0 +1,51 @@
+package fs
+
+// DriverCall denotes a driver function or a function of a file opened by a driver. It is used by wrapping drivers to select or report calls.
+type DriverCall string
+
+const (
+	// CallExists denotes calls to Exists.
+	CallExists DriverCall = "Exists"
+	// CallIsFile denotes calls to IsFile.
+	CallIsFile DriverCall = "IsFile"
+	// CallIsDir denotes calls to IsDir.
+	CallIsDir DriverCall = "IsDir"
+	// CallStat denotes calls to Stat.
+	CallStat DriverCall = "Stat"
+	// CallReadDir denotes calls to ReadDir.
+	CallReadDir DriverCall = "ReadDir"
+	// CallOpenFile denotes calls to OpenFile.
+	CallOpenFile DriverCall = "OpenFile"
+	// CallCreateDirectory denotes calls to CreateDirectory.
+	CallCreateDirectory DriverCall = "CreateDirectory"
+	// CallDeleteFile denotes calls to DeleteFile.
+	CallDeleteFile DriverCall = "DeleteFile"
+	// CallDeleteDirectory denotes calls to DeleteDirectory.
+	CallDeleteDirectory DriverCall = "DeleteDirectory"
+	// CallMoveFile denotes calls to MoveFile.
+	CallMoveFile DriverCall = "MoveFile"
+	// CallMoveDir denotes calls to MoveDir.
+	CallMoveDir DriverCall = "MoveDir"
+	// CallListDir denotes calls to ListDir of DirLister.
+	CallListDir DriverCall = "ListDir"
+	// CallLockFile denotes calls to LockFile of LockFileSystemDriver.
+	CallLockFile DriverCall = "LockFile"
+	// CallUnlockFile denotes calls to UnlockFile of LockFileSystemDriver.
+	CallUnlockFile DriverCall = "UnlockFile"
+	// CallWatch denotes calls to Watch of WatchFileSystemDriver.
+	CallWatch DriverCall = "Watch"
+	// CallReadMetadata denotes calls to ReadMetadata of MetadataFileSystemDriver.
+	CallReadMetadata DriverCall = "ReadMetadata"
+	// CallWriteMetadata denotes calls to WriteMetadata of MetadataFileSystemDriver.
+	CallWriteMetadata DriverCall = "WriteMetadata"
+	// CallHash denotes calls to Hash of HashFileSystemDriver.
+	CallHash DriverCall = "Hash"
+	// CallVolumeInfo denotes calls to VolumeInfo of VolumeInfoDriver.
+	CallVolumeInfo DriverCall = "VolumeInfo"
+	// CallRead denotes calls to Read of an opened file.
+	CallRead DriverCall = "Read"
+	// CallWrite denotes calls to Write of an opened file.
+	CallWrite DriverCall = "Write"
+	// CallClose denotes calls to Close of an opened file.
+	CallClose DriverCall = "Close"
+)
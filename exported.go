@@ -1,6 +1,8 @@
 package fs
 
 import (
+	"time"
+
 	"github.com/sbreitf1/errors"
 )
 
@@ -38,6 +40,11 @@ func ReadDir(path string) ([]FileInfo, errors.Error) {
 	return DefaultFileSystem.ReadDir(path)
 }
 
+// ListDir returns a cursor to list all files and directories contained in a directory in batches.
+func ListDir(path string) (DirCursor, errors.Error) {
+	return DefaultFileSystem.ListDir(path)
+}
+
 // Walk calls the corresponding callback functions for ever file and directory contained in dir recursively.
 //
 // The visit handler is called first for every file and directory that is found inside a directory. For directories, the enter dir handler is called subsequently. After this call, Walk instantly recurses into the given directory. Remaining files in the parent directory are visited after the corresponding leave callback. Leave callbacks are performed directly after the last element of a directory has been visited (and leaved in case of a sub-directory).
@@ -45,6 +52,26 @@ func Walk(dir string, visitFileHandler VisitFileHandler, enterDirHandler EnterDi
 	return DefaultFileSystem.Walk(dir, visitFileHandler, enterDirHandler, leaveDirHandler, options)
 }
 
+// NewWalker returns a new iterator for all files and directories contained in dir recursively.
+func NewWalker(dir string, options *WalkOptions) *Walker {
+	return DefaultFileSystem.NewWalker(dir, options)
+}
+
+// DiskUsage recursively computes the number of files and directories and the total size of a directory.
+func DiskUsage(dir string, options *DiskUsageOptions) (*DiskUsageStats, errors.Error) {
+	return DefaultFileSystem.DiskUsage(dir, options)
+}
+
+// VolumeInfo returns information about the volume the given path is located on.
+func VolumeInfo(path string) (VolumeStats, errors.Error) {
+	return DefaultFileSystem.VolumeInfo(path)
+}
+
+// EnsureFreeSpace returns ErrInsufficientSpace when the volume of the given path does not offer the required number of bytes.
+func EnsureFreeSpace(path string, requiredBytes uint64) errors.Error {
+	return DefaultFileSystem.EnsureFreeSpace(path, requiredBytes)
+}
+
 // Open opens a file instance for reading and returns the handle.
 func Open(path string) (File, errors.Error) {
 	return DefaultFileSystem.Open(path)
@@ -70,6 +97,26 @@ func ReadLines(path string) ([]string, errors.Error) {
 	return DefaultFileSystem.ReadLines(path)
 }
 
+// Hash returns the checksum of a file.
+func Hash(path string, algo HashAlgorithm) (Checksum, errors.Error) {
+	return DefaultFileSystem.Hash(path, algo)
+}
+
+// HashContent reads the content of a file and returns its checksum ignoring stored checksums.
+func HashContent(path string, algo HashAlgorithm) (Checksum, errors.Error) {
+	return DefaultFileSystem.HashContent(path, algo)
+}
+
+// HashTree returns a deterministic checksum of a directory that covers the names, types and contents of all contained files and directories recursively.
+func HashTree(dir string, algo HashAlgorithm) (Checksum, errors.Error) {
+	return DefaultFileSystem.HashTree(dir, algo)
+}
+
+// VerifyFile reads the content of a file and returns ErrChecksumMismatch when it does not match the expected checksum.
+func VerifyFile(path string, algo HashAlgorithm, expected Checksum) errors.Error {
+	return DefaultFileSystem.VerifyFile(path, algo, expected)
+}
+
 // CreateFile a new file (or truncate an existing) and return the file instance handle.
 func CreateFile(path string) (File, errors.Error) {
 	return DefaultFileSystem.CreateFile(path)
@@ -95,6 +142,11 @@ func WriteLines(path string, lines []string) errors.Error {
 	return DefaultFileSystem.WriteLines(path, lines)
 }
 
+// CloseWrittenFile closes a file that has been opened for writing and returns all errors that occured while closing.
+func CloseWrittenFile(f File, path string) errors.Error {
+	return DefaultFileSystem.CloseWrittenFile(f, path)
+}
+
 // DeleteFile deletes a file.
 func DeleteFile(path string) errors.Error {
 	return DefaultFileSystem.DeleteFile(path)
@@ -130,26 +182,116 @@ func Copy(src, dst string) errors.Error {
 	return DefaultFileSystem.Copy(src, dst)
 }
 
+// CopyWithOptions clone a file or directory to the target using the given copy options. If the target already exists, it must be the same element type (file or directory) to be overwritten.
+func CopyWithOptions(src, dst string, options *CopyOptions) errors.Error {
+	return DefaultFileSystem.CopyWithOptions(src, dst, options)
+}
+
 // CopyFile clones a file and overwrites the existing one.
 func CopyFile(src, dst string) errors.Error {
 	return DefaultFileSystem.CopyFile(src, dst)
 }
 
+// CopyFileWithOptions clones a file using the given copy options and overwrites the existing one.
+func CopyFileWithOptions(src, dst string, options *CopyOptions) errors.Error {
+	return DefaultFileSystem.CopyFileWithOptions(src, dst, options)
+}
+
 // CopyDir recursively clones a directory overwriting all existing files.
 func CopyDir(src, dst string) errors.Error {
 	return DefaultFileSystem.CopyDir(src, dst)
 }
 
+// CopyDirWithOptions recursively clones a directory using the given copy options overwriting all existing files.
+func CopyDirWithOptions(src, dst string, options *CopyOptions) errors.Error {
+	return DefaultFileSystem.CopyDirWithOptions(src, dst, options)
+}
+
 // CopyAll copies all files and directories contained in src to dst.
 func CopyAll(src, dst string) errors.Error {
 	return DefaultFileSystem.CopyAll(src, dst)
 }
 
+// CopyAllWithOptions copies all files and directories contained in src to dst using the given copy options.
+func CopyAllWithOptions(src, dst string, options *CopyOptions) errors.Error {
+	return DefaultFileSystem.CopyAllWithOptions(src, dst, options)
+}
+
 // CleanDir removes all files and directories from a directory.
 func CleanDir(path string) errors.Error {
 	return DefaultFileSystem.CleanDir(path)
 }
 
+// PlanCleanDir returns the operations performed by CleanDir without applying them.
+func PlanCleanDir(dir string) (*Plan, errors.Error) {
+	return DefaultFileSystem.PlanCleanDir(dir)
+}
+
+// PlanDeleteDirectory returns the operations performed by DeleteDirectory without applying them.
+func PlanDeleteDirectory(dir string, recursive bool) (*Plan, errors.Error) {
+	return DefaultFileSystem.PlanDeleteDirectory(dir, recursive)
+}
+
+// PlanMoveAll returns the operations performed by MoveAll without applying them.
+func PlanMoveAll(src, dst string) (*Plan, errors.Error) {
+	return DefaultFileSystem.PlanMoveAll(src, dst)
+}
+
+// ApplyPlan performs all operations of a plan in the given order and stops at the first error.
+func ApplyPlan(plan *Plan) errors.Error {
+	return DefaultFileSystem.ApplyPlan(plan)
+}
+
+// NewTx returns a new transaction that uses a unique sub-directory of stagingDir for overwritten and deleted elements.
+func NewTx(stagingDir string) *Tx {
+	return DefaultFileSystem.NewTx(stagingDir)
+}
+
+// RecoverTx rolls back all transactions that have been interrupted while using stagingDir and removes their staging data.
+func RecoverTx(stagingDir string) errors.Error {
+	return DefaultFileSystem.RecoverTx(stagingDir)
+}
+
+// ReadMetadata returns the attributes of a file or directory.
+func ReadMetadata(path string) (*Metadata, errors.Error) {
+	return DefaultFileSystem.ReadMetadata(path)
+}
+
+// WriteMetadata applies all known attributes to a file or directory.
+func WriteMetadata(path string, metadata *Metadata) errors.Error {
+	return DefaultFileSystem.WriteMetadata(path, metadata)
+}
+
+// Watch returns a watcher that reports changes of a file or the content of a directory.
+func Watch(path string, recursive bool) (Watcher, errors.Error) {
+	return DefaultFileSystem.Watch(path, recursive)
+}
+
+// WatchWithOptions returns a watcher that reports changes of a file or the content of a directory using the given options.
+func WatchWithOptions(path string, recursive bool, options *WatchOptions) (Watcher, errors.Error) {
+	return DefaultFileSystem.WatchWithOptions(path, recursive, options)
+}
+
+// Lock acquires an advisory lock on a file that is created if it does not exist.
+func Lock(path string, mode LockMode, blocking bool) (*FileLock, errors.Error) {
+	return DefaultFileSystem.Lock(path, mode, blocking)
+}
+
+// WithLock acquires an exclusive lock on path and releases it when f returns.
+func WithLock(path string, f func() errors.Error) errors.Error {
+	return DefaultFileSystem.WithLock(path, f)
+}
+
+// AcquireLockFile creates a lock file that is exclusive across processes and hosts sharing the file system.
+func AcquireLockFile(path string, ttl time.Duration) (*LockFile, errors.Error) {
+	return DefaultFileSystem.AcquireLockFile(path, ttl)
+}
+
+// ReadLockFile returns the owner stored in a lock file.
+func ReadLockFile(path string) (*LockFileOwner, errors.Error) {
+	return DefaultFileSystem.ReadLockFile(path)
+}
+
 // GetTempFile returns the path to an empty temporary file.
 func GetTempFile(pattern string) (string, errors.Error) {
 	return DefaultFileSystem.GetTempFile(pattern)
@@ -0,0 +1,306 @@
+package fs
+
+import (
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/sbreitf1/fs/path"
+
+	"github.com/sbreitf1/errors"
+)
+
+var (
+	// ErrInjectedFault is returned by a FaultDriver for failing operations without explicit error.
+	ErrInjectedFault = errors.New("Injected fault in %s of %q")
+)
+
+// FaultKind denotes the behavior of an injected fault.
+type FaultKind int
+
+const (
+	// FaultError causes the operation to fail without being performed.
+	FaultError FaultKind = iota
+	// FaultShortWrite causes Write to only write half of the data and return io.ErrShortWrite.
+	FaultShortWrite
+	// FaultDelay causes the operation to be delayed before it is performed.
+	FaultDelay
+	// FaultCorruptWrite causes Write to silently alter the last byte of the data while reporting success. It is used to test verification of written data.
+	FaultCorruptWrite
+)
+
+// Fault describes a failure injected by a FaultDriver.
+type Fault struct {
+	// Call denotes the affected driver or file function. Leave empty to affect all calls.
+	Call DriverCall
+	// Path is a pattern as accepted by path.Match that selects the affected paths. Moves are affected when source or destination match. Leave empty to affect all paths.
+	Path string
+	Kind FaultKind
+	// NthCall restricts the fault to the n-th affected call starting with 1. Leave zero to affect all calls.
+	NthCall int
+	// Probability denotes the chance of an affected call to fail in the range (0, 1]. Leave zero to always fail.
+	Probability float64
+	// Err is returned by failing operations. Defaults to ErrInjectedFault.
+	Err errors.Error
+	// Delay denotes the duration of FaultDelay.
+	Delay time.Duration
+}
+
+type faultState struct {
+	Fault
+	calls int
+}
+
+func (f *faultState) matches(call DriverCall, paths []string) bool {
+	if len(f.Call) > 0 && f.Call != call {
+		return false
+	}
+	if len(f.Path) == 0 {
+		return true
+	}
+	for _, p := range paths {
+		if matched, _ := path.Match(f.Path, p); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// FaultDriver wraps a driver and injects failures to test the behavior of applications on partial failures. Random faults are reproducible for the same seed and sequence of calls.
+//
+// Faults can only be injected into the basic driver and file functions and into locks. Other optional interfaces of the wrapped driver are hidden, so that copies fall back to reading and writing files.
+type FaultDriver struct {
+	driver ReadWriteFileSystemDriver
+	mutex  sync.Mutex
+	faults []*faultState
+	rand   *rand.Rand
+}
+
+// NewFaultDriver returns a driver that wraps driver without any faults. The seed is used for faults with probability.
+func NewFaultDriver(driver ReadWriteFileSystemDriver, seed int64) *FaultDriver {
+	return &FaultDriver{driver: driver, rand: rand.New(rand.NewSource(seed))}
+}
+
+// AddFault injects a new fault for all following calls.
+func (d *FaultDriver) AddFault(fault Fault) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.faults = append(d.faults, &faultState{Fault: fault})
+}
+
+// ClearFaults removes all faults.
+func (d *FaultDriver) ClearFaults() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.faults = nil
+}
+
+// writeFault denotes how a write is altered by injected faults.
+type writeFault struct {
+	short   bool
+	corrupt bool
+}
+
+// inject applies all faults affecting the call and returns how a write should be altered and the error to fail with.
+func (d *FaultDriver) inject(call DriverCall, paths ...string) (writeFault, errors.Error) {
+	var err errors.Error
+	var delay time.Duration
+	var wf writeFault
+
+	d.mutex.Lock()
+	for _, f := range d.faults {
+		if !f.matches(call, paths) {
+			continue
+		}
+		f.calls++
+		if f.NthCall > 0 && f.calls != f.NthCall {
+			continue
+		}
+		if f.Probability > 0 && d.rand.Float64() >= f.Probability {
+			continue
+		}
+
+		switch f.Kind {
+		case FaultDelay:
+			delay += f.Delay
+		case FaultShortWrite:
+			wf.short = true
+		case FaultCorruptWrite:
+			wf.corrupt = true
+		default:
+			if err == nil {
+				if f.Err != nil {
+					err = f.Err
+				} else {
+					err = ErrInjectedFault.Args(call, paths[0]).Make()
+				}
+			}
+		}
+	}
+	d.mutex.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	return wf, err
+}
+
+// Exists returns true, if the given path is a file or directory.
+func (d *FaultDriver) Exists(path string) (bool, errors.Error) {
+	if _, err := d.inject(CallExists, path); err != nil {
+		return false, err
+	}
+	return d.driver.Exists(path)
+}
+
+// IsFile returns true, if the given path is a file.
+func (d *FaultDriver) IsFile(path string) (bool, errors.Error) {
+	if _, err := d.inject(CallIsFile, path); err != nil {
+		return false, err
+	}
+	return d.driver.IsFile(path)
+}
+
+// IsDir returns true, if the given path is a directory.
+func (d *FaultDriver) IsDir(path string) (bool, errors.Error) {
+	if _, err := d.inject(CallIsDir, path); err != nil {
+		return false, err
+	}
+	return d.driver.IsDir(path)
+}
+
+// Stat returns file or directory stats for a given path.
+func (d *FaultDriver) Stat(path string) (FileInfo, errors.Error) {
+	if _, err := d.inject(CallStat, path); err != nil {
+		return nil, err
+	}
+	return d.driver.Stat(path)
+}
+
+// ReadDir returns all files and directories contained in a directory.
+func (d *FaultDriver) ReadDir(path string) ([]FileInfo, errors.Error) {
+	if _, err := d.inject(CallReadDir, path); err != nil {
+		return nil, err
+	}
+	return d.driver.ReadDir(path)
+}
+
+// OpenFile opens a file instance and returns the handle. Reads, writes and closing of the returned file are subject to fault injection.
+func (d *FaultDriver) OpenFile(path string, flags OpenFlags) (File, errors.Error) {
+	if _, err := d.inject(CallOpenFile, path); err != nil {
+		return nil, err
+	}
+	f, err := d.driver.OpenFile(path, flags)
+	if err != nil {
+		return nil, err
+	}
+	return wrapFile(&faultFile{File: f, driver: d, path: path}, f), nil
+}
+
+// CreateDirectory creates a new directory and all parent directories if they do not exist.
+func (d *FaultDriver) CreateDirectory(path string) errors.Error {
+	if _, err := d.inject(CallCreateDirectory, path); err != nil {
+		return err
+	}
+	return d.driver.CreateDirectory(path)
+}
+
+// DeleteFile deletes a file.
+func (d *FaultDriver) DeleteFile(path string) errors.Error {
+	if _, err := d.inject(CallDeleteFile, path); err != nil {
+		return err
+	}
+	return d.driver.DeleteFile(path)
+}
+
+// DeleteDirectory deletes a directory.
+func (d *FaultDriver) DeleteDirectory(path string, recursive bool) errors.Error {
+	if _, err := d.inject(CallDeleteDirectory, path); err != nil {
+		return err
+	}
+	return d.driver.DeleteDirectory(path, recursive)
+}
+
+// MoveFile moves a file to a new location.
+func (d *FaultDriver) MoveFile(src, dst string) errors.Error {
+	if _, err := d.inject(CallMoveFile, src, dst); err != nil {
+		return err
+	}
+	return d.driver.MoveFile(src, dst)
+}
+
+// MoveDir moves a directory to a new location.
+func (d *FaultDriver) MoveDir(src, dst string) errors.Error {
+	if _, err := d.inject(CallMoveDir, src, dst); err != nil {
+		return err
+	}
+	return d.driver.MoveDir(src, dst)
+}
+
+// LockFile acquires an advisory lock on a file opened by this driver.
+func (d *FaultDriver) LockFile(f File, path string, mode LockMode, blocking bool) errors.Error {
+	driver, ok := d.driver.(LockFileSystemDriver)
+	if !ok {
+		return ErrNotSupported.Args("LockFile").Make()
+	}
+	if _, err := d.inject(CallLockFile, path); err != nil {
+		return err
+	}
+	return driver.LockFile(unwrapFile(f), path, mode, blocking)
+}
+
+// UnlockFile releases an advisory lock on a file opened by this driver.
+func (d *FaultDriver) UnlockFile(f File, path string) errors.Error {
+	driver, ok := d.driver.(LockFileSystemDriver)
+	if !ok {
+		return ErrNotSupported.Args("UnlockFile").Make()
+	}
+	if _, err := d.inject(CallUnlockFile, path); err != nil {
+		return err
+	}
+	return driver.UnlockFile(unwrapFile(f), path)
+}
+
+type faultFile struct {
+	File
+	driver *FaultDriver
+	path   string
+}
+
+func (f *faultFile) Read(p []byte) (int, error) {
+	if _, err := f.driver.inject(CallRead, f.path); err != nil {
+		return 0, err
+	}
+	return f.File.Read(p)
+}
+
+func (f *faultFile) Write(p []byte) (int, error) {
+	wf, err := f.driver.inject(CallWrite, f.path)
+	if err != nil {
+		return 0, err
+	}
+	if wf.corrupt && len(p) > 0 {
+		// never modify the buffer of the caller
+		corrupted := append([]byte(nil), p...)
+		corrupted[len(corrupted)-1] ^= 0xff
+		p = corrupted
+	}
+	if wf.short && len(p) > 0 {
+		n, err := f.File.Write(p[:len(p)/2])
+		if err != nil {
+			return n, err
+		}
+		return n, io.ErrShortWrite
+	}
+	return f.File.Write(p)
+}
+
+func (f *faultFile) Close() error {
+	if _, err := f.driver.inject(CallClose, f.path); err != nil {
+		// release the underlying file anyway to not leak handles
+		f.File.Close()
+		return err
+	}
+	return f.File.Close()
+}
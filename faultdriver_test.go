@@ -0,0 +1,136 @@
+package fs
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFaultDriver(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		driver := NewFaultDriver(&LocalDriver{Root: tmpDir}, 42)
+		fs := NewWithDriver(driver)
+
+		t.Run("TestNthCall", func(t *testing.T) {
+			defer driver.ClearFaults()
+			driver.AddFault(Fault{Call: CallOpenFile, Path: "/*.txt", NthCall: 2})
+
+			errors.AssertNil(t, fs.WriteString("/a.txt", "foo"))
+			errors.Assert(t, ErrInjectedFault, fs.WriteString("/b.txt", "foo"))
+			assertNotExists(t, fs, "/b.txt")
+			errors.AssertNil(t, fs.WriteString("/b.txt", "foo"))
+			// other paths are not counted
+			errors.AssertNil(t, fs.WriteString("/c.dat", "foo"))
+		})
+
+		t.Run("TestCustomError", func(t *testing.T) {
+			defer driver.ClearFaults()
+			driver.AddFault(Fault{Call: CallDeleteFile, Err: ErrAccessDenied.Args("/a.txt").Make()})
+
+			errors.Assert(t, ErrAccessDenied, fs.DeleteFile("/a.txt"))
+			assertIsFile(t, fs, "/a.txt")
+		})
+
+		t.Run("TestMove", func(t *testing.T) {
+			defer driver.ClearFaults()
+			driver.AddFault(Fault{Call: CallMoveFile, Path: "/target.txt"})
+
+			errors.Assert(t, ErrInjectedFault, fs.MoveFile("/a.txt", "/target.txt"))
+			assertIsFile(t, fs, "/a.txt")
+		})
+
+		t.Run("TestProbability", func(t *testing.T) {
+			defer driver.ClearFaults()
+
+			run := func() []bool {
+				d := NewFaultDriver(&LocalDriver{Root: tmpDir}, 7)
+				d.AddFault(Fault{Call: CallStat, Probability: 0.5})
+				failed := make([]bool, 20)
+				for i := range failed {
+					_, err := d.Stat("/a.txt")
+					failed[i] = err != nil
+				}
+				return failed
+			}
+
+			failed := run()
+			assert.Contains(t, failed, true)
+			assert.Contains(t, failed, false)
+			assert.Equal(t, failed, run())
+		})
+
+		t.Run("TestWriteError", func(t *testing.T) {
+			defer driver.ClearFaults()
+			driver.AddFault(Fault{Call: CallWrite, Err: ErrAccessDenied.Args("/denied.txt").Make()})
+
+			// typed write errors are passed to the caller
+			errors.Assert(t, ErrAccessDenied, fs.WriteString("/denied.txt", "bar"))
+			errors.Assert(t, ErrAccessDenied, fs.CopyFile("/c.dat", "/copy.dat"))
+		})
+
+		t.Run("TestShortWrite", func(t *testing.T) {
+			defer driver.ClearFaults()
+			driver.AddFault(Fault{Call: CallWrite, Kind: FaultShortWrite})
+
+			f, err := fs.CreateFile("/short.txt")
+			errors.AssertNil(t, err)
+			n, writeErr := f.Write([]byte("foobar"))
+			assert.Equal(t, 3, n)
+			assert.Equal(t, io.ErrShortWrite, writeErr)
+			assert.NoError(t, f.Close())
+			assertFileContent(t, fs, "/short.txt", "foo")
+		})
+
+		t.Run("TestCorruptWrite", func(t *testing.T) {
+			defer driver.ClearFaults()
+			driver.AddFault(Fault{Call: CallWrite, Kind: FaultCorruptWrite})
+
+			data := []byte("foobar")
+			f, err := fs.CreateFile("/corrupt.txt")
+			errors.AssertNil(t, err)
+			n, writeErr := f.Write(data)
+			assert.Equal(t, 6, n)
+			assert.NoError(t, writeErr)
+			assert.NoError(t, f.Close())
+			assert.Equal(t, "foobar", string(data))
+			driver.ClearFaults()
+			assertFileContent(t, fs, "/corrupt.txt", "fooba\x8d")
+		})
+
+		t.Run("TestSlowRead", func(t *testing.T) {
+			defer driver.ClearFaults()
+			driver.AddFault(Fault{Call: CallRead, Path: "/a.txt", Kind: FaultDelay, Delay: 20 * time.Millisecond})
+
+			start := time.Now()
+			assertFileContent(t, fs, "/a.txt", "foo")
+			assert.True(t, time.Since(start) >= 20*time.Millisecond)
+		})
+
+		t.Run("TestFailingClose", func(t *testing.T) {
+			defer driver.ClearFaults()
+			driver.AddFault(Fault{Call: CallClose})
+
+			// close errors are reported as cause
+			errors.Assert(t, Err, fs.WriteString("/close.txt", "foo"))
+			driver.ClearFaults()
+			assertFileContent(t, fs, "/close.txt", "foo")
+		})
+
+		t.Run("TestCopyDir", func(t *testing.T) {
+			defer driver.ClearFaults()
+			errors.AssertNil(t, fs.CreateDirectory("/src/sub"))
+			errors.AssertNil(t, fs.WriteString("/src/file1.txt", "foo"))
+			errors.AssertNil(t, fs.WriteString("/src/sub/file2.txt", "bar"))
+			driver.AddFault(Fault{Call: CallOpenFile, Path: "/dst/sub/*"})
+
+			assert.NotNil(t, fs.CopyDir("/src", "/dst"))
+			assertFileContent(t, fs, "/src/sub/file2.txt", "bar")
+			assertNotExists(t, fs, "/dst/sub/file2.txt")
+		})
+
+		return nil
+	}))
+}
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/sbreitf1/fs/path"
 
@@ -15,6 +16,8 @@ import (
 const (
 	// DefaultLineDelimiter denotes the character or characther sequence to separate lines in text files.
 	DefaultLineDelimiter = "\n"
+	// DefaultListBatchSize denotes the number of directory elements read at once when listing directories in batches.
+	DefaultListBatchSize = 1000
 )
 
 var (
@@ -28,10 +31,16 @@ var (
 	ErrFileNotExists = errors.New("The file %q does not exist")
 	// ErrDirectoryNotExists occurs when an action failed because of a missing directory.
 	ErrDirectoryNotExists = errors.New("The directory %q does not exist")
+	// ErrAlreadyExists occurs when exclusively creating a file that already exists.
+	ErrAlreadyExists = errors.New("The path %q already exists")
 	// ErrAccessDenied denotes an error caused by insufficient privileges.
 	ErrAccessDenied = errors.New("Access to %q denied")
 	// ErrNotEmpty occurs when trying to delete a non-empty directory without recursive flag.
 	ErrNotEmpty = errors.New("The directory is not empty")
+	// ErrChecksumMismatch occurs when the content of a file does not match the expected checksum.
+	ErrChecksumMismatch = errors.New("Checksum mismatch for %q: expected %s but got %s")
+	// ErrInsufficientSpace occurs when the target volume does not offer enough space for an operation.
+	ErrInsufficientSpace = errors.New("Insufficient space on target volume: %d bytes required but only %d bytes available")
 )
 
 // NavigationFileSystemDriver describes functionality to list files and directories but does not allow access to file content.
@@ -44,6 +53,18 @@ type NavigationFileSystemDriver interface {
 	ReadDir(path string) ([]FileInfo, errors.Error)
 }
 
+// DirLister describes optional functionality to list the content of large directories in batches instead of reading the whole listing at once. Drivers may return ErrNotSupported to fall back to ReadDir.
+type DirLister interface {
+	ListDir(path string) (DirCursor, errors.Error)
+}
+
+// DirCursor iterates over the content of a directory in batches. It must be closed after usage.
+type DirCursor interface {
+	// Next returns the next batch of at most n elements. An empty batch is returned when all elements have been listed. Elements read before an error occured are returned together with the error.
+	Next(n int) ([]FileInfo, errors.Error)
+	Close() errors.Error
+}
+
 // ReadFileSystemDriver describes functionality to read from a file system.
 type ReadFileSystemDriver interface {
 	NavigationFileSystemDriver
@@ -84,6 +105,13 @@ type FileInfo interface {
 	IsDir() bool
 }
 
+// ExtendedFileInfo contains additional meta information that is offered by most drivers.
+type ExtendedFileInfo interface {
+	FileInfo
+	Mode() os.FileMode
+	ModTime() time.Time
+}
+
 // File is the instance object for an opened file.
 type File interface {
 	io.Reader
@@ -155,6 +183,18 @@ type FileSystem struct {
 	tmpDriver                               TempFileSystemDriver
 	canNavigate, canRead, canWrite, canTemp bool
 	LineSeparator                           string
+	// SyncWrites causes all write operations to flush written files to stable storage before they are closed. Only applies to files that implement Syncer.
+	SyncWrites bool
+}
+
+// Syncer describes a file that can be flushed to stable storage.
+type Syncer interface {
+	Sync() error
+}
+
+// Truncater describes a file that can be cut to a given size.
+type Truncater interface {
+	Truncate(size int64) error
 }
 
 // New returns a new file system with local file system driver.
@@ -172,7 +212,7 @@ func NewWithDriver(driver interface{}) *FileSystem {
 		//TODO show message if driver is not passed as pointer
 		panic(fmt.Sprintf("fs.New expects valid File System Driver but got %T instead", driver))
 	}
-	return &FileSystem{navDriver, rDriver, rwDriver, tmpDriver, navDriverOk, rDriverOk, rwDriverOk, tmpDriverOk, DefaultLineDelimiter}
+	return &FileSystem{navDriver, rDriver, rwDriver, tmpDriver, navDriverOk, rDriverOk, rwDriverOk, tmpDriverOk, DefaultLineDelimiter, false}
 }
 
 // CanNavigate returns true when the file system allows to list files and directories.
@@ -254,6 +294,63 @@ func (fs *FileSystem) ReadDir(path string) ([]FileInfo, errors.Error) {
 	return fs.navDriver.ReadDir(path)
 }
 
+// ListDir returns a cursor to list all files and directories contained in a directory in batches. Drivers that do not support DirLister are listed using ReadDir.
+func (fs *FileSystem) ListDir(path string) (DirCursor, errors.Error) {
+	if !fs.canNavigate {
+		return nil, ErrNotSupported.Args("ListDir").Make()
+	}
+
+	if lister, ok := fs.navDriver.(DirLister); ok {
+		cursor, err := lister.ListDir(path)
+		if err == nil || !errors.InstanceOf(err, ErrNotSupported) {
+			return cursor, err
+		}
+	}
+
+	files, err := fs.navDriver.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	return &sliceDirCursor{files}, nil
+}
+
+// listDirBatches calls f for every batch of DefaultListBatchSize elements contained in a directory. Elements read before an error occured are passed to f before the error is returned.
+func (fs *FileSystem) listDirBatches(dir string, f func(files []FileInfo)) errors.Error {
+	cursor, err := fs.ListDir(dir)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close()
+
+	for {
+		files, err := cursor.Next(DefaultListBatchSize)
+		if len(files) > 0 {
+			f(files)
+		}
+		if err != nil || len(files) == 0 {
+			return err
+		}
+	}
+}
+
+type sliceDirCursor struct {
+	files []FileInfo
+}
+
+func (c *sliceDirCursor) Next(n int) ([]FileInfo, errors.Error) {
+	if n <= 0 || n > len(c.files) {
+		n = len(c.files)
+	}
+	batch := c.files[:n]
+	c.files = c.files[n:]
+	return batch, nil
+}
+
+func (c *sliceDirCursor) Close() errors.Error {
+	c.files = nil
+	return nil
+}
+
 // VisitFileHandler is called by Walk for every file and directory that is found recursively.
 type VisitFileHandler func(dir string, f FileInfo, isRoot bool) errors.Error
 
@@ -273,6 +370,8 @@ type WalkOptions struct {
 	EnterLeaveCallbacksForRoot bool
 	// VisitOrder denotes a function that is used to sort the sequence of files inside a single directory to specify in which order the sub-elements are processed.
 	VisitOrder FileInfoComparer
+	// ListBatchSize enables reading huge directories in batches of the given number of elements from drivers implementing DirLister. Elements are then visited in the order of the driver listing, which is not sorted for LocalDriver. Directories are read completely using ReadDir if ListBatchSize is 0 or VisitOrder is set.
+	ListBatchSize int
 }
 
 // Walk calls the corresponding callback functions for ever file and directory contained in dir recursively.
@@ -323,19 +422,46 @@ func (fs *FileSystem) Walk(dir string, visitFileHandler VisitFileHandler, enterD
 }
 
 func (fs *FileSystem) walk(dir string, visitFileHandler VisitFileHandler, enterDirHandler EnterDirHandler, leaveDirHandler LeaveDirHandler, options *WalkOptions) errors.Error {
-	files, err := fs.ReadDir(dir)
+	if options.VisitOrder != nil || options.ListBatchSize <= 0 {
+		files, err := fs.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+
+		if options.VisitOrder != nil {
+			Sort(files, options.VisitOrder)
+		}
+		_, err = fs.walkFiles(dir, files, visitFileHandler, enterDirHandler, leaveDirHandler, options)
+		return err
+	}
+
+	cursor, err := fs.ListDir(dir)
 	if err != nil {
 		return err
 	}
+	defer cursor.Close()
 
-	if options.VisitOrder != nil {
-		Sort(files, options.VisitOrder)
+	for {
+		files, listErr := cursor.Next(options.ListBatchSize)
+		if len(files) == 0 {
+			return listErr
+		}
+
+		stop, err := fs.walkFiles(dir, files, visitFileHandler, enterDirHandler, leaveDirHandler, options)
+		if err != nil || stop {
+			return err
+		}
+		if listErr != nil {
+			return listErr
+		}
 	}
+}
 
+func (fs *FileSystem) walkFiles(dir string, files []FileInfo, visitFileHandler VisitFileHandler, enterDirHandler EnterDirHandler, leaveDirHandler LeaveDirHandler, options *WalkOptions) (bool, errors.Error) {
 	for _, f := range files {
 		if visitFileHandler != nil {
 			if err := visitFileHandler(dir, f, false); err != nil {
-				return err
+				return false, err
 			}
 		}
 
@@ -343,26 +469,26 @@ func (fs *FileSystem) walk(dir string, visitFileHandler VisitFileHandler, enterD
 			if enterDirHandler != nil {
 				skipDir := false
 				if err := enterDirHandler(dir, f, false, &skipDir); err != nil {
-					return err
+					return false, err
 				}
 				if skipDir {
-					return nil
+					return true, nil
 				}
 			}
 
 			if err := fs.walk(path.Join(dir, f.Name()), visitFileHandler, enterDirHandler, leaveDirHandler, options); err != nil {
-				return err
+				return false, err
 			}
 
 			if leaveDirHandler != nil {
 				if err := leaveDirHandler(dir, f, false); err != nil {
-					return err
+					return false, err
 				}
 			}
 		}
 	}
 
-	return nil
+	return false, nil
 }
 
 /* ############################################### */
@@ -472,11 +598,32 @@ func (fs *FileSystem) WriteBytes(path string, content []byte) errors.Error {
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
 	if _, err := f.Write(content); err != nil {
+		f.Close()
+		// keep typed errors of wrapping drivers
+		if e, ok := err.(errors.Error); ok {
+			return e
+		}
 		return Err.Msg("Failed to write file").Make().Cause(err)
 	}
+	return fs.CloseWrittenFile(f, path)
+}
+
+// CloseWrittenFile closes a file that has been opened for writing. The file is flushed to stable storage before if SyncWrites is set. Other than a deferred Close this returns all errors, because many drivers only report failed writes when closing the file.
+func (fs *FileSystem) CloseWrittenFile(f File, path string) errors.Error {
+	if fs.SyncWrites {
+		if syncer, ok := f.(Syncer); ok {
+			if err := syncer.Sync(); err != nil {
+				f.Close()
+				return Err.Msg("Failed to sync file %q", path).Make().Cause(err)
+			}
+		}
+	}
+
+	if err := f.Close(); err != nil {
+		return Err.Msg("Failed to close file %q", path).Make().Cause(err)
+	}
 	return nil
 }
 
@@ -618,8 +765,43 @@ func (fs *FileSystem) MoveAll(src, dst string) errors.Error {
 //TODO MoveDir with callback before overwrite (cancel/skip/overwrite/rename) -> maybe replace existing MoveDir method?
 // -> specify default handlers for cancel / skip / overwrite and rename by adding a number
 
+// CopyFileSystemDriver describes optional functionality for drivers that can copy files and directories natively without streaming the content through the file system. Drivers should return ErrNotSupported when a native copy is not possible.
+type CopyFileSystemDriver interface {
+	CopyFile(src, dst string) errors.Error
+	CopyDir(src, dst string) errors.Error
+}
+
+// CopyOptions can be used to specify the behavior of copy operations.
+type CopyOptions struct {
+	// CheckFreeSpace causes the copy operation to fail with ErrInsufficientSpace before any data is copied when the destination volume does not offer enough space. The check is skipped for drivers that do not implement VolumeInfoDriver, which includes LocalDriver on platforms other than Linux, macOS, FreeBSD, DragonFly BSD and Windows.
+	CheckFreeSpace bool
+	// Verify denotes a hash algorithm that is used to compute a checksum of all copied data. The destination files are read again after copying and ErrChecksumMismatch is returned when the content differs. Leave empty to skip verification.
+	Verify HashAlgorithm
+	// Parallelism denotes the maximum number of files that are copied concurrently by CopyDir and CopyAll. Values below 2 copy all files sequentially and stop at the first error, otherwise all files are processed and the errors are aggregated.
+	Parallelism int
+	// PreserveMode transfers the permission bits of copied files and directories.
+	PreserveMode bool
+	// PreserveModTime transfers the modification time of copied files and directories.
+	PreserveModTime bool
+	// PreserveOwner transfers the numeric user and group id of copied files and directories. Usually requires elevated privileges.
+	PreserveOwner bool
+	// PreserveXattrs transfers the extended attributes of copied files and directories.
+	PreserveXattrs bool
+	// Resume continues copying a file after the data of an existing partial destination file instead of starting from the beginning. Native copies of CopyFileSystemDriver are not used when resuming.
+	Resume bool
+	// ResumeVerify denotes a hash algorithm used to compare the existing destination data with the source chunk by chunk. Copying is continued after the last matching chunk. Leave empty to only compare file sizes.
+	ResumeVerify HashAlgorithm
+	// ResumeChunkSize denotes the size of chunks compared for ResumeVerify. Defaults to DefaultResumeChunkSize.
+	ResumeChunkSize int64
+}
+
 // Copy clone a file or directory to the target. If the target already exists, it must be the same element type (file or directory) to be overwritten.
 func (fs *FileSystem) Copy(src, dst string) errors.Error {
+	return fs.CopyWithOptions(src, dst, nil)
+}
+
+// CopyWithOptions clone a file or directory to the target using the given copy options. If the target already exists, it must be the same element type (file or directory) to be overwritten.
+func (fs *FileSystem) CopyWithOptions(src, dst string, options *CopyOptions) errors.Error {
 	if !fs.canWrite {
 		return ErrNotSupported.Args("Copy").Make()
 	}
@@ -629,7 +811,7 @@ func (fs *FileSystem) Copy(src, dst string) errors.Error {
 		return err
 	}
 	if isFile {
-		return fs.CopyFile(src, dst)
+		return fs.CopyFileWithOptions(src, dst, options)
 	}
 
 	isDir, err := fs.IsDir(src)
@@ -637,7 +819,7 @@ func (fs *FileSystem) Copy(src, dst string) errors.Error {
 		return err
 	}
 	if isDir {
-		return fs.CopyDir(src, dst)
+		return fs.CopyDirWithOptions(src, dst, options)
 	}
 
 	return ErrNotExists.Args(src).Make()
@@ -645,82 +827,121 @@ func (fs *FileSystem) Copy(src, dst string) errors.Error {
 
 // CopyFile clones a file and overwrites the existing one.
 func (fs *FileSystem) CopyFile(src, dst string) errors.Error {
+	return fs.CopyFileWithOptions(src, dst, nil)
+}
+
+// CopyFileWithOptions clones a file using the given copy options and overwrites the existing one.
+func (fs *FileSystem) CopyFileWithOptions(src, dst string, options *CopyOptions) errors.Error {
 	if !fs.canWrite {
 		return ErrNotSupported.Args("CopyFile").Make()
 	}
 
-	reader, err := fs.Open(src)
-	if err != nil {
-		return err
+	if options == nil {
+		options = &CopyOptions{}
 	}
-	defer reader.Close()
 
-	writer, err := fs.CreateFile(dst)
-	if err != nil {
-		return err
+	if options.CheckFreeSpace {
+		fi, err := fs.Stat(src)
+		if err != nil {
+			return err
+		}
+		if err := fs.EnsureFreeSpace(path.Dir(dst), uint64(fi.Size())); err != nil {
+			return err
+		}
 	}
-	defer writer.Close()
 
-	if _, err := io.Copy(writer, reader); err != nil {
-		return Err.Msg("Failed to copy file").Make().Cause(err)
+	return fs.copyFile(src, dst, options)
+}
+
+func (fs *FileSystem) copyFile(src, dst string, options *CopyOptions) errors.Error {
+	if err := CopyFileContent(fs, src, fs, dst, options); err != nil {
+		return err
 	}
-	return nil
+	return CopyMetadata(fs, src, fs, dst, options)
 }
 
 // CopyDir recursively clones a directory overwriting all existing files.
 func (fs *FileSystem) CopyDir(src, dst string) errors.Error {
+	return fs.CopyDirWithOptions(src, dst, nil)
+}
+
+// CopyDirWithOptions recursively clones a directory using the given copy options overwriting all existing files.
+func (fs *FileSystem) CopyDirWithOptions(src, dst string, options *CopyOptions) errors.Error {
 	if !fs.canWrite {
 		return ErrNotSupported.Args("CopyDir").Make()
 	}
 
-	if err := fs.rwDriver.CreateDirectory(dst); err != nil {
-		return err
+	if options == nil {
+		options = &CopyOptions{}
 	}
 
-	files, err := fs.rDriver.ReadDir(src)
-	if err != nil {
-		return err
+	if options.CheckFreeSpace {
+		if err := fs.ensureFreeSpaceForDir(src, path.Dir(dst)); err != nil {
+			return err
+		}
 	}
 
-	for _, f := range files {
-		if f.IsDir() {
-			if err := fs.CopyDir(path.Join(src, f.Name()), path.Join(dst, f.Name())); err != nil {
-				return err
-			}
-		} else {
-			if err := fs.CopyFile(path.Join(src, f.Name()), path.Join(dst, f.Name())); err != nil {
-				return err
-			}
+	return fs.copyDir(src, dst, options)
+}
+
+func (fs *FileSystem) copyDir(src, dst string, options *CopyOptions) errors.Error {
+	// verification, metadata, resuming and parallel copies are handled per file
+	if driver, ok := fs.nativeCopyDriver(); ok && len(options.Verify) == 0 && !options.preservesMetadata() && !options.Resume && options.Parallelism < 2 {
+		err := driver.CopyDir(src, dst)
+		if err == nil || !errors.InstanceOf(err, ErrNotSupported) {
+			return err
 		}
 	}
 
-	return nil
+	if err := fs.rwDriver.CreateDirectory(dst); err != nil {
+		return err
+	}
+
+	if err := CopyTree(fs, src, fs, dst, options); err != nil {
+		return err
+	}
+	return CopyMetadata(fs, src, fs, dst, options)
 }
 
 // CopyAll copies all files and directories contained in src to dst.
 func (fs *FileSystem) CopyAll(src, dst string) errors.Error {
+	return fs.CopyAllWithOptions(src, dst, nil)
+}
+
+// CopyAllWithOptions copies all files and directories contained in src to dst using the given copy options.
+func (fs *FileSystem) CopyAllWithOptions(src, dst string, options *CopyOptions) errors.Error {
 	if !fs.canWrite {
 		return ErrNotSupported.Args("CopyAll").Make()
 	}
 
-	files, err := fs.rDriver.ReadDir(src)
-	if err != nil {
-		return err
+	if options == nil {
+		options = &CopyOptions{}
 	}
 
-	for _, f := range files {
-		if f.IsDir() {
-			if err := fs.CopyDir(path.Join(src, f.Name()), path.Join(dst, f.Name())); err != nil {
-				return err
-			}
-		} else {
-			if err := fs.CopyFile(path.Join(src, f.Name()), path.Join(dst, f.Name())); err != nil {
-				return err
-			}
+	if options.CheckFreeSpace {
+		if err := fs.ensureFreeSpaceForDir(src, dst); err != nil {
+			return err
 		}
 	}
 
-	return nil
+	return CopyTree(fs, src, fs, dst, options)
+}
+
+// nativeCopyDriver returns the driver when it implements CopyFileSystemDriver. Native copies are not used for SyncWrites, because the written files cannot be synced.
+func (fs *FileSystem) nativeCopyDriver() (CopyFileSystemDriver, bool) {
+	if fs.SyncWrites {
+		return nil, false
+	}
+	driver, ok := fs.navDriver.(CopyFileSystemDriver)
+	return driver, ok
+}
+
+func (fs *FileSystem) ensureFreeSpaceForDir(src, dst string) errors.Error {
+	usage, err := fs.DiskUsage(src, &DiskUsageOptions{LargestFilesCount: -1})
+	if err != nil {
+		return err
+	}
+	return fs.EnsureFreeSpace(dst, uint64(usage.TotalBytes))
 }
 
 //TODO CopyDir with callback before overwrite (cancel/skip/overwrite/rename)
@@ -3,6 +3,7 @@ package fs
 import (
 	"io/ioutil"
 	"os"
+	"sort"
 	"testing"
 
 	"github.com/sbreitf1/fs/path"
@@ -179,6 +180,17 @@ func testFS(t *testing.T, fs *FileSystem, dir string) {
 		assertWalk(t, fs, path.Join(dir, "foo"), &WalkOptions{EnterLeaveCallbacksForRoot: true}, []string{"bar", "test.txt"}, []string{"foo", "bar"}, []string{"bar", "foo"})
 	})
 
+	t.Run("TestWalkBatched", func(t *testing.T) {
+		// batched listings are not sorted
+		visited := make([]string, 0)
+		errors.AssertNil(t, fs.Walk(path.Join(dir, "foo"), func(dir string, f FileInfo, isRoot bool) errors.Error {
+			visited = append(visited, f.Name())
+			return nil
+		}, nil, nil, &WalkOptions{ListBatchSize: 1}))
+		sort.Strings(visited)
+		assert.Equal(t, []string{"bar", "test.txt"}, visited)
+	})
+
 	t.Run("TestWalkFlat", func(t *testing.T) {
 		assertWalk(t, fs, path.Join(dir, "foo"), &WalkOptions{SkipSubDirs: true}, []string{"bar"}, []string{}, []string{})
 	})
@@ -297,12 +309,210 @@ func testFS(t *testing.T, fs *FileSystem, dir string) {
 	t.Run("TestWalkCompound", func(t *testing.T) {
 		assertWalk(t, fs, path.Join(dir, "foo2"), &WalkOptions{VisitOrder: NewCompoundComparer(OrderFilesFirst, OrderLexicographicAsc)}, []string{"better stuff.txt", "test.txt", "bar", "cool", "sub", "stuff"}, []string{"bar", "cool", "sub", "stuff"}, []string{"bar", "sub", "cool", "stuff"})
 	})
+
+	t.Run("TestWalker", func(t *testing.T) {
+		assertWalker(t, fs.NewWalker(path.Join(dir, "foo2"), &WalkOptions{VisitOrder: OrderLexicographicAsc}), nil, []string{"bar", "better stuff.txt", "cool", "sub", "stuff", "test.txt"})
+	})
+
+	t.Run("TestWalkerVisitRoot", func(t *testing.T) {
+		assertWalker(t, fs.NewWalker(path.Join(dir, "foo2"), &WalkOptions{VisitRootDir: true, VisitOrder: OrderFilesFirst}), nil, []string{"foo2", "better stuff.txt", "test.txt", "bar", "cool", "sub", "stuff"})
+	})
+
+	t.Run("TestWalkerFlat", func(t *testing.T) {
+		assertWalker(t, fs.NewWalker(path.Join(dir, "foo2"), &WalkOptions{SkipSubDirs: true, VisitOrder: OrderLexicographicDesc}), nil, []string{"test.txt", "stuff", "cool", "better stuff.txt", "bar"})
+	})
+
+	t.Run("TestWalkerSkipDir", func(t *testing.T) {
+		assertWalker(t, fs.NewWalker(path.Join(dir, "foo2"), &WalkOptions{VisitOrder: OrderLexicographicAsc}), []string{"cool"}, []string{"bar", "better stuff.txt", "cool", "stuff", "test.txt"})
+	})
+
+	t.Run("TestWalkerSkipRoot", func(t *testing.T) {
+		assertWalker(t, fs.NewWalker(path.Join(dir, "foo2"), &WalkOptions{VisitRootDir: true}), []string{"foo2"}, []string{"foo2"})
+	})
+
+	t.Run("TestWalkerBatched", func(t *testing.T) {
+		// batched listings are not sorted
+		w := fs.NewWalker(path.Join(dir, "foo2"), &WalkOptions{ListBatchSize: 2})
+		visited := make([]string, 0)
+		for w.Next() {
+			visited = append(visited, w.Entry().Info.Name())
+		}
+		errors.AssertNil(t, w.Err())
+		sort.Strings(visited)
+		assert.Equal(t, []string{"bar", "better stuff.txt", "cool", "stuff", "sub", "test.txt"}, visited)
+	})
+
+	t.Run("TestWalkerClose", func(t *testing.T) {
+		w := fs.NewWalker(path.Join(dir, "foo2"), &WalkOptions{ListBatchSize: 1})
+		assert.True(t, w.Next())
+		w.Close()
+		assert.False(t, w.Next())
+		errors.AssertNil(t, w.Err())
+	})
+
+	t.Run("TestWalkerPath", func(t *testing.T) {
+		w := fs.NewWalker(path.Join(dir, "foo2"), &WalkOptions{VisitOrder: OrderLexicographicAsc})
+		for w.Next() {
+			if w.Entry().Info.Name() == "sub" {
+				assert.Equal(t, path.Join(dir, "foo2/cool/sub"), w.Entry().Path())
+				break
+			}
+		}
+		errors.AssertNil(t, w.Err())
+	})
+
+	t.Run("TestWalkerNonExistent", func(t *testing.T) {
+		w := fs.NewWalker(path.Join(dir, "nonexistingdir"), nil)
+		assert.False(t, w.Next())
+		errors.Assert(t, ErrNotExists, w.Err())
+	})
+}
+
+func TestCloseErrors(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		fs := NewWithDriver(&LocalDriver{Root: tmpDir})
+		errors.AssertNil(t, fs.CreateDirectory("/src/sub"))
+		errors.AssertNil(t, fs.WriteString("/src/sub/test.txt", "foo bar"))
+
+		failingFS := NewWithDriver(&failingCloseDriver{&LocalDriver{Root: tmpDir}})
+		errors.Assert(t, Err, failingFS.WriteString("/test.txt", "foo bar"))
+		errors.Assert(t, Err, failingFS.WriteLines("/test.txt", []string{"foo", "bar"}))
+		errors.Assert(t, Err, failingFS.CopyFile("/src/sub/test.txt", "/test.txt"))
+		errors.Assert(t, Err, failingFS.CopyDir("/src", "/dst"))
+		errors.Assert(t, Err, failingFS.CopyAll("/src", "/"))
+
+		// reading is not affected by close errors
+		assertFileContent(t, failingFS, "/src/sub/test.txt", "foo bar")
+		return nil
+	}))
+}
+
+func TestSyncWrites(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		driver := &syncCountingDriver{LocalDriver: &LocalDriver{Root: tmpDir}}
+		fs := NewWithDriver(driver)
+		errors.AssertNil(t, fs.WriteString("/test.txt", "foo bar"))
+		assert.Equal(t, 0, driver.syncCount)
+
+		fs.SyncWrites = true
+		errors.AssertNil(t, fs.WriteString("/test.txt", "foo bar"))
+		errors.AssertNil(t, fs.CopyFile("/test.txt", "/test2.txt"))
+		assert.Equal(t, 2, driver.syncCount)
+		assertFileContent(t, fs, "/test2.txt", "foo bar")
+		return nil
+	}))
+}
+
+func TestNativeCopy(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		driver := &nativeCopyCountingDriver{LocalDriver: &LocalDriver{Root: tmpDir}}
+		fs := NewWithDriver(driver)
+		errors.AssertNil(t, fs.CreateDirectory("/src/sub"))
+		errors.AssertNil(t, fs.WriteString("/src/sub/test.txt", "foo bar"))
+
+		errors.AssertNil(t, fs.CopyFile("/src/sub/test.txt", "/test.txt"))
+		errors.AssertNil(t, fs.CopyDir("/src", "/dst"))
+		assert.Equal(t, 1, driver.fileCount)
+		assert.Equal(t, 1, driver.dirCount)
+		assertFileContent(t, fs, "/test.txt", "foo bar")
+		assertFileContent(t, fs, "/dst/sub/test.txt", "foo bar")
+
+		// directories are copied file by file for verification
+		errors.AssertNil(t, fs.CopyDirWithOptions("/src", "/dst2", &CopyOptions{Verify: HashSHA256}))
+		assert.Equal(t, 2, driver.fileCount)
+		assert.Equal(t, 1, driver.dirCount)
+		assertFileContent(t, fs, "/dst2/sub/test.txt", "foo bar")
+
+		// parallel copies use the copy pool and copy files natively
+		errors.AssertNil(t, fs.CopyDirWithOptions("/src", "/dst4", &CopyOptions{Parallelism: 4}))
+		assert.Equal(t, 3, driver.fileCount)
+		assert.Equal(t, 1, driver.dirCount)
+		assertFileContent(t, fs, "/dst4/sub/test.txt", "foo bar")
+
+		// native copies cannot be synced
+		fs.SyncWrites = true
+		errors.AssertNil(t, fs.Copy("/src", "/dst3"))
+		assert.Equal(t, 3, driver.fileCount)
+		assert.Equal(t, 1, driver.dirCount)
+		assertFileContent(t, fs, "/dst3/sub/test.txt", "foo bar")
+		return nil
+	}))
 }
 
 /* ############################################### */
 /* ###               Test Helper               ### */
 /* ############################################### */
 
+type nativeCopyCountingDriver struct {
+	*LocalDriver
+	fileCount int
+	dirCount  int
+}
+
+func (d *nativeCopyCountingDriver) CopyFile(src, dst string) errors.Error {
+	d.fileCount++
+	return d.LocalDriver.CopyFile(src, dst)
+}
+
+func (d *nativeCopyCountingDriver) CopyDir(src, dst string) errors.Error {
+	d.dirCount++
+	return d.LocalDriver.CopyDir(src, dst)
+}
+
+type failingCloseDriver struct {
+	*LocalDriver
+}
+
+// CopyFile disables native copies to always write through OpenFile.
+func (d *failingCloseDriver) CopyFile(src, dst string) errors.Error {
+	return ErrNotSupported.Args("CopyFile").Make()
+}
+
+// CopyDir disables native copies to always write through OpenFile.
+func (d *failingCloseDriver) CopyDir(src, dst string) errors.Error {
+	return ErrNotSupported.Args("CopyDir").Make()
+}
+
+func (d *failingCloseDriver) OpenFile(path string, flags OpenFlags) (File, errors.Error) {
+	f, err := d.LocalDriver.OpenFile(path, flags)
+	if err != nil || !flags.IsWrite() {
+		return f, err
+	}
+	return &failingCloseFile{f}, nil
+}
+
+type failingCloseFile struct {
+	File
+}
+
+func (f *failingCloseFile) Close() error {
+	f.File.Close()
+	return errors.New("Failed to flush buffered data").Make()
+}
+
+type syncCountingDriver struct {
+	*LocalDriver
+	syncCount int
+}
+
+func (d *syncCountingDriver) OpenFile(path string, flags OpenFlags) (File, errors.Error) {
+	f, err := d.LocalDriver.OpenFile(path, flags)
+	if err != nil || !flags.IsWrite() {
+		return f, err
+	}
+	return &syncCountingFile{f, d}, nil
+}
+
+type syncCountingFile struct {
+	File
+	driver *syncCountingDriver
+}
+
+func (f *syncCountingFile) Sync() error {
+	f.driver.syncCount++
+	return nil
+}
+
 func assertNotExists(t *testing.T, fs *FileSystem, path string) bool {
 	exists, err := fs.Exists(path)
 	if errors.AssertNil(t, err, "Error while checking for %q", path) {
@@ -413,3 +623,22 @@ func assertWalk(t *testing.T, fs *FileSystem, path string, options *WalkOptions,
 
 	return true
 }
+
+func assertWalker(t *testing.T, w *Walker, skipDirs, visitExpected []string) bool {
+	skip := make(map[string]bool)
+	for _, d := range skipDirs {
+		skip[d] = true
+	}
+
+	visited := make([]string, 0)
+	for w.Next() {
+		visited = append(visited, w.Entry().Info.Name())
+		if skip[w.Entry().Info.Name()] {
+			w.SkipDir()
+		}
+	}
+	if !errors.AssertNil(t, w.Err()) {
+		return false
+	}
+	return assert.Equal(t, visitExpected, visited)
+}
@@ -1,15 +1,15 @@
 module github.com/sbreitf1/fs
 
 require (
-	github.com/konsorten/go-windows-terminal-sequences v1.0.2 // indirect
+	github.com/cespare/xxhash/v2 v2.1.2
 	github.com/sbreitf1/errors v1.1.0
-	github.com/sirupsen/logrus v1.4.2 // indirect
 	github.com/stretchr/testify v1.3.0
-	golang.org/x/crypto v0.0.0-20190701094942-4def268fd1a4 // indirect
-	golang.org/x/net v0.0.0-20190724013045-ca1201d0de80 // indirect
-	golang.org/x/sys v0.0.0-20190804053845-51ab0e2deafa // indirect
-	golang.org/x/text v0.3.2 // indirect
-	golang.org/x/tools v0.0.0-20190806215303-88ddfcebc769 // indirect
+	golang.org/x/text v0.13.0
 )
 
-go 1.13
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+)
+
+go 1.17
@@ -0,0 +1,184 @@
+package fs
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"sort"
+
+	"github.com/sbreitf1/fs/path"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/sbreitf1/errors"
+)
+
+// HashAlgorithm denotes a hash function used to compute file checksums.
+type HashAlgorithm string
+
+const (
+	// HashMD5 denotes the MD5 hash function.
+	HashMD5 HashAlgorithm = "md5"
+	// HashSHA1 denotes the SHA-1 hash function.
+	HashSHA1 HashAlgorithm = "sha1"
+	// HashSHA256 denotes the SHA-256 hash function.
+	HashSHA256 HashAlgorithm = "sha256"
+	// HashXXHash denotes the 64 bit xxHash function.
+	HashXXHash HashAlgorithm = "xxhash"
+)
+
+// New returns a new hash instance for the algorithm.
+func (algo HashAlgorithm) New() (hash.Hash, errors.Error) {
+	switch algo {
+	case HashMD5:
+		return md5.New(), nil
+	case HashSHA1:
+		return sha1.New(), nil
+	case HashSHA256:
+		return sha256.New(), nil
+	case HashXXHash:
+		return xxhash.New(), nil
+	default:
+		return nil, ErrNotSupported.Msg("Hash algorithm %q is not supported", string(algo)).Make()
+	}
+}
+
+// Checksum contains the digest of a hash function.
+type Checksum []byte
+
+// String returns the hex representation of the checksum.
+func (c Checksum) String() string {
+	return hex.EncodeToString(c)
+}
+
+// HashFileSystemDriver describes optional functionality for drivers that can return stored checksums without reading file content. Drivers should return ErrNotSupported when no checksum is available for the requested algorithm.
+type HashFileSystemDriver interface {
+	Hash(path string, algo HashAlgorithm) (Checksum, errors.Error)
+}
+
+// Hash returns the checksum of a file. Stored checksums are used for drivers that implement HashFileSystemDriver, otherwise the file content is read.
+func (fs *FileSystem) Hash(path string, algo HashAlgorithm) (Checksum, errors.Error) {
+	if driver, ok := fs.navDriver.(HashFileSystemDriver); ok {
+		checksum, err := driver.Hash(path, algo)
+		if err == nil {
+			return checksum, nil
+		}
+		if !errors.InstanceOf(err, ErrNotSupported) {
+			return nil, err
+		}
+	}
+
+	if !fs.canRead {
+		return nil, ErrNotSupported.Args("Hash").Make()
+	}
+
+	return fs.HashContent(path, algo)
+}
+
+// HashContent reads the content of a file and returns its checksum. Other than Hash, stored checksums of HashFileSystemDriver are ignored.
+func (fs *FileSystem) HashContent(path string, algo HashAlgorithm) (Checksum, errors.Error) {
+	if !fs.canRead {
+		return nil, ErrNotSupported.Args("HashContent").Make()
+	}
+
+	h, err := algo.New()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := fs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, Err.Msg("Failed to read file").Make().Cause(err)
+	}
+	return h.Sum(nil), nil
+}
+
+// VerifyFile reads the content of a file and returns ErrChecksumMismatch when it does not match the expected checksum. Stored checksums of HashFileSystemDriver are ignored.
+func (fs *FileSystem) VerifyFile(path string, algo HashAlgorithm, expected Checksum) errors.Error {
+	if !fs.canRead {
+		return ErrNotSupported.Args("VerifyFile").Make()
+	}
+
+	checksum, err := fs.HashContent(path, algo)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(checksum, expected) {
+		return ErrChecksumMismatch.Args(path, expected.String(), checksum.String()).Make()
+	}
+	return nil
+}
+
+// HashTree returns a deterministic checksum of a directory that covers the names, types and contents of all contained files and directories recursively. Two directories have the same checksum when they contain the same tree.
+func (fs *FileSystem) HashTree(dir string, algo HashAlgorithm) (Checksum, errors.Error) {
+	root, err := algo.New()
+	if err != nil {
+		return nil, err
+	}
+
+	// directories are listed in batches, so the nodes of a directory are sorted before hashing them
+	stack := [][]treeNode{nil}
+	err = fs.Walk(dir, func(dir string, f FileInfo, isRoot bool) errors.Error {
+		if f.IsDir() {
+			return nil
+		}
+
+		checksum, err := fs.Hash(path.Join(dir, f.Name()), algo)
+		if err != nil {
+			return err
+		}
+		stack[len(stack)-1] = append(stack[len(stack)-1], treeNode{'f', f.Name(), checksum})
+		return nil
+	}, func(dir string, f FileInfo, isRoot bool, skipDir *bool) errors.Error {
+		stack = append(stack, nil)
+		return nil
+	}, func(dir string, f FileInfo, isRoot bool) errors.Error {
+		h, err := algo.New()
+		if err != nil {
+			return err
+		}
+		writeTreeNodes(h, stack[len(stack)-1])
+		stack = stack[:len(stack)-1]
+		stack[len(stack)-1] = append(stack[len(stack)-1], treeNode{'d', f.Name(), h.Sum(nil)})
+		return nil
+	}, &WalkOptions{ListBatchSize: DefaultListBatchSize})
+	if err != nil {
+		return nil, err
+	}
+
+	writeTreeNodes(root, stack[0])
+	return root.Sum(nil), nil
+}
+
+type treeNode struct {
+	nodeType byte
+	name     string
+	checksum []byte
+}
+
+// writeTreeNodes writes all nodes of a directory ordered by name.
+func writeTreeNodes(h hash.Hash, nodes []treeNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return compareStrings(nodes[i].name, nodes[j].name) < 0
+	})
+	for _, n := range nodes {
+		writeTreeNode(h, n.nodeType, n.name, n.checksum)
+	}
+}
+
+func writeTreeNode(h hash.Hash, nodeType byte, name string, checksum []byte) {
+	h.Write([]byte{nodeType})
+	h.Write([]byte(name))
+	// file names cannot contain null bytes and checksums have a fixed length
+	h.Write([]byte{0})
+	h.Write(checksum)
+}
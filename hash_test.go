@@ -0,0 +1,156 @@
+package fs
+
+import (
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type storedHashDriver struct {
+	*LocalDriver
+}
+
+func (d *storedHashDriver) Hash(path string, algo HashAlgorithm) (Checksum, errors.Error) {
+	if algo == HashSHA256 {
+		return Checksum{0x13, 0x37}, nil
+	}
+	return nil, ErrNotSupported.Args("Hash").Make()
+}
+
+func TestHash(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		fs := NewWithDriver(&LocalDriver{Root: tmpDir})
+		errors.AssertNil(t, fs.WriteString("/test.txt", "foo bar"))
+
+		assertHash(t, fs, "/test.txt", HashMD5, "327b6f07435811239bc47e1544353273")
+		assertHash(t, fs, "/test.txt", HashSHA1, "3773dea65156909838fa6c22825cafe090ff8030")
+		assertHash(t, fs, "/test.txt", HashSHA256, "fbc1a9f858ea9e177916964bd88c3d37b91a1e84412765e29950777f265c4b75")
+
+		checksum, err := fs.Hash("/test.txt", HashXXHash)
+		if errors.AssertNil(t, err) {
+			assert.Len(t, checksum, 8)
+		}
+
+		_, err = fs.Hash("/test.txt", HashAlgorithm("crc1337"))
+		errors.Assert(t, ErrNotSupported, err)
+
+		_, err = fs.Hash("/nonexisting.txt", HashMD5)
+		errors.Assert(t, ErrFileNotExists, err)
+
+		storedFS := NewWithDriver(&storedHashDriver{&LocalDriver{Root: tmpDir}})
+		assertHash(t, storedFS, "/test.txt", HashSHA256, "1337")
+		assertHash(t, storedFS, "/test.txt", HashMD5, "327b6f07435811239bc47e1544353273")
+
+		// stored checksums are ignored when reading the content
+		checksum, err = storedFS.HashContent("/test.txt", HashSHA256)
+		if errors.AssertNil(t, err) {
+			assert.Equal(t, "fbc1a9f858ea9e177916964bd88c3d37b91a1e84412765e29950777f265c4b75", checksum.String())
+		}
+		return nil
+	}))
+}
+
+func TestHashTree(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		fs := NewWithDriver(&LocalDriver{Root: tmpDir})
+		errors.AssertNil(t, fs.CreateDirectory("/a/sub/empty"))
+		errors.AssertNil(t, fs.WriteString("/a/test.txt", "foo"))
+		errors.AssertNil(t, fs.WriteString("/a/sub/test.txt", "bar"))
+		errors.AssertNil(t, fs.CopyDir("/a", "/b"))
+
+		checksumA := assertHashTree(t, fs, "/a")
+		assert.Equal(t, checksumA, assertHashTree(t, fs, "/b"))
+
+		errors.AssertNil(t, fs.WriteString("/b/sub/test.txt", "baz"))
+		checksumB := assertHashTree(t, fs, "/b")
+		assert.NotEqual(t, checksumA, checksumB)
+
+		errors.AssertNil(t, fs.WriteString("/b/sub/test.txt", "bar"))
+		errors.AssertNil(t, fs.DeleteDirectory("/b/sub/empty", false))
+		checksumB = assertHashTree(t, fs, "/b")
+		assert.NotEqual(t, checksumA, checksumB)
+
+		errors.AssertNil(t, fs.CreateDirectory("/b/sub/empty2"))
+		checksumB = assertHashTree(t, fs, "/b")
+		assert.NotEqual(t, checksumA, checksumB)
+
+		errors.AssertNil(t, fs.MoveDir("/b/sub/empty2", "/b/sub/empty"))
+		assert.Equal(t, checksumA, assertHashTree(t, fs, "/b"))
+		return nil
+	}))
+}
+
+func assertHash(t *testing.T, fs *FileSystem, path string, algo HashAlgorithm, expectedChecksum string) bool {
+	checksum, err := fs.Hash(path, algo)
+	if errors.AssertNil(t, err, "Error while hashing %q", path) {
+		return assert.Equal(t, expectedChecksum, checksum.String(), "Unexpected %s checksum of %q", algo, path)
+	}
+	return false
+}
+
+func assertHashTree(t *testing.T, fs *FileSystem, dir string) string {
+	checksum, err := fs.HashTree(dir, HashSHA256)
+	errors.AssertNil(t, err, "Error while hashing %q", dir)
+	return checksum.String()
+}
+
+type corruptingDriver struct {
+	*LocalDriver
+}
+
+// CopyFile disables native copies to always write through OpenFile.
+func (d *corruptingDriver) CopyFile(src, dst string) errors.Error {
+	return ErrNotSupported.Args("CopyFile").Make()
+}
+
+// CopyDir disables native copies to always write through OpenFile.
+func (d *corruptingDriver) CopyDir(src, dst string) errors.Error {
+	return ErrNotSupported.Args("CopyDir").Make()
+}
+
+func (d *corruptingDriver) OpenFile(path string, flags OpenFlags) (File, errors.Error) {
+	f, err := d.LocalDriver.OpenFile(path, flags)
+	if err != nil || !flags.IsWrite() {
+		return f, err
+	}
+	return &corruptingFile{f}, nil
+}
+
+type corruptingFile struct {
+	File
+}
+
+func (f *corruptingFile) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	// silently drop the last byte
+	n, err := f.File.Write(p[:len(p)-1])
+	if err != nil {
+		return n, err
+	}
+	return len(p), nil
+}
+
+func TestCopyVerify(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		fs := NewWithDriver(&LocalDriver{Root: tmpDir})
+		errors.AssertNil(t, fs.CreateDirectory("/src/sub"))
+		errors.AssertNil(t, fs.WriteString("/src/test.txt", "foo bar"))
+		errors.AssertNil(t, fs.WriteString("/src/sub/test.txt", "foo bar"))
+
+		errors.AssertNil(t, fs.CopyFileWithOptions("/src/test.txt", "/out.txt", &CopyOptions{Verify: HashSHA256}))
+		assertFileContent(t, fs, "/out.txt", "foo bar")
+		errors.AssertNil(t, fs.CopyDirWithOptions("/src", "/dst", &CopyOptions{Verify: HashXXHash}))
+		assertFileContent(t, fs, "/dst/sub/test.txt", "foo bar")
+
+		errors.Assert(t, ErrNotSupported, fs.CopyFileWithOptions("/src/test.txt", "/out.txt", &CopyOptions{Verify: HashAlgorithm("crc1337")}))
+
+		corruptFS := NewWithDriver(&corruptingDriver{&LocalDriver{Root: tmpDir}})
+		errors.AssertNil(t, corruptFS.CopyFile("/src/test.txt", "/corrupt.txt"))
+		errors.Assert(t, ErrChecksumMismatch, corruptFS.CopyFileWithOptions("/src/test.txt", "/corrupt.txt", &CopyOptions{Verify: HashMD5}))
+		errors.Assert(t, ErrChecksumMismatch, corruptFS.CopyAllWithOptions("/src", "/corrupt", &CopyOptions{Verify: HashMD5}))
+		return nil
+	}))
+}
@@ -66,3 +66,107 @@ func assertFileContent(t *testing.T, fs *fs.FileSystem, path, expectedContent st
 	}
 	return false
 }
+
+type corruptingDriver struct {
+	*fs.LocalDriver
+}
+
+func (d *corruptingDriver) OpenFile(path string, flags fs.OpenFlags) (fs.File, errors.Error) {
+	f, err := d.LocalDriver.OpenFile(path, flags)
+	if err != nil || !flags.IsWrite() {
+		return f, err
+	}
+	return &corruptingFile{f}, nil
+}
+
+type corruptingFile struct {
+	fs.File
+}
+
+func (f *corruptingFile) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	// silently drop the last byte
+	n, err := f.File.Write(p[:len(p)-1])
+	if err != nil {
+		return n, err
+	}
+	return len(p), nil
+}
+
+type failingCloseDriver struct {
+	*fs.LocalDriver
+}
+
+func (d *failingCloseDriver) OpenFile(path string, flags fs.OpenFlags) (fs.File, errors.Error) {
+	f, err := d.LocalDriver.OpenFile(path, flags)
+	if err != nil || !flags.IsWrite() {
+		return f, err
+	}
+	return &failingCloseFile{f}, nil
+}
+
+type failingCloseFile struct {
+	fs.File
+}
+
+func (f *failingCloseFile) Close() error {
+	f.File.Close()
+	return errors.New("Failed to flush buffered data").Make()
+}
+
+// interferingDriver calls onWrite every time a file is opened for writing.
+type interferingDriver struct {
+	*fs.LocalDriver
+	onWrite func(path string)
+}
+
+func (d *interferingDriver) OpenFile(path string, flags fs.OpenFlags) (fs.File, errors.Error) {
+	if flags.IsWrite() && d.onWrite != nil {
+		d.onWrite(path)
+	}
+	return d.LocalDriver.OpenFile(path, flags)
+}
+
+// noModTimeDriver returns file infos without modification times.
+type noModTimeDriver struct {
+	*fs.LocalDriver
+}
+
+func (d *noModTimeDriver) Stat(path string) (fs.FileInfo, errors.Error) {
+	fi, err := d.LocalDriver.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return basicFileInfo{fi}, nil
+}
+
+func (d *noModTimeDriver) ReadDir(path string) ([]fs.FileInfo, errors.Error) {
+	files, err := d.LocalDriver.ReadDir(path)
+	for i := range files {
+		files[i] = basicFileInfo{files[i]}
+	}
+	return files, err
+}
+
+// ListDir disables batched listings to always return file infos of ReadDir.
+func (d *noModTimeDriver) ListDir(path string) (fs.DirCursor, errors.Error) {
+	return nil, fs.ErrNotSupported.Args("ListDir").Make()
+}
+
+type basicFileInfo struct {
+	fi fs.FileInfo
+}
+
+func (f basicFileInfo) Name() string {
+	return f.fi.Name()
+}
+
+func (f basicFileInfo) Size() int64 {
+	return f.fi.Size()
+}
+
+func (f basicFileInfo) IsDir() bool {
+	return f.fi.IsDir()
+}
@@ -0,0 +1,97 @@
+package interop
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/sbreitf1/fs"
+
+	"github.com/sbreitf1/errors"
+)
+
+// CompareMode denotes how files are compared to detect changes.
+type CompareMode int
+
+const (
+	// CompareSizeAndModTime compares files by size and modification time. Files of the same size are compared by content if a driver does not provide modification times.
+	CompareSizeAndModTime CompareMode = iota
+	// CompareSize compares files only by size.
+	CompareSize
+	// CompareModTime compares files only by modification time. Files are compared by size and content if a driver does not provide modification times.
+	CompareModTime
+	// CompareChecksum compares files by the checksum of their content.
+	CompareChecksum
+)
+
+func modTime(f fs.FileInfo) (time.Time, bool) {
+	if ext, ok := f.(fs.ExtendedFileInfo); ok {
+		return ext.ModTime(), true
+	}
+	return time.Time{}, false
+}
+
+func checksumsEqual(fsA *fs.FileSystem, a string, fsB *fs.FileSystem, b string, algo fs.HashAlgorithm) (bool, errors.Error) {
+	if len(algo) == 0 {
+		algo = fs.HashSHA256
+	}
+
+	checksumA, err := fsA.Hash(a, algo)
+	if err != nil {
+		return false, err
+	}
+	checksumB, err := fsB.Hash(b, algo)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(checksumA, checksumB), nil
+}
+
+func filesDiffer(fsA *fs.FileSystem, a string, fA fs.FileInfo, fsB *fs.FileSystem, b string, fB fs.FileInfo, mode CompareMode, algo fs.HashAlgorithm) (bool, errors.Error) {
+	switch mode {
+	case CompareChecksum:
+		return contentsDiffer(fsA, a, fA, fsB, b, fB, algo)
+
+	case CompareSize:
+		return fA.Size() != fB.Size(), nil
+
+	case CompareModTime:
+		if !hasModTimes(fA, fB) {
+			return contentsDiffer(fsA, a, fA, fsB, b, fB, algo)
+		}
+		return !modTimesEqual(fA, fB), nil
+
+	default:
+		if fA.Size() != fB.Size() {
+			return true, nil
+		}
+		if !hasModTimes(fA, fB) {
+			return contentsDiffer(fsA, a, fA, fsB, b, fB, algo)
+		}
+		return !modTimesEqual(fA, fB), nil
+	}
+}
+
+func contentsDiffer(fsA *fs.FileSystem, a string, fA fs.FileInfo, fsB *fs.FileSystem, b string, fB fs.FileInfo, algo fs.HashAlgorithm) (bool, errors.Error) {
+	if fA.Size() != fB.Size() {
+		return true, nil
+	}
+	equal, err := checksumsEqual(fsA, a, fsB, b, algo)
+	return !equal, err
+}
+
+// hasModTimes returns true if both files provide modification times.
+func hasModTimes(fA, fB fs.FileInfo) bool {
+	_, okA := modTime(fA)
+	_, okB := modTime(fB)
+	return okA && okB
+}
+
+func modTimesEqual(fA, fB fs.FileInfo) bool {
+	timeA, okA := modTime(fA)
+	timeB, okB := modTime(fB)
+	if !okA || !okB {
+		// cannot decide without modification times -> assume equal
+		return true
+	}
+	return timeA.Equal(timeB)
+}
@@ -1,8 +1,6 @@
 package interop
 
 import (
-	"io"
-
 	"github.com/sbreitf1/fs"
 	"github.com/sbreitf1/fs/path"
 
@@ -11,6 +9,11 @@ import (
 
 // Copy copies a file or directory from one file system to another recursively.
 func Copy(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string) errors.Error {
+	return CopyWithOptions(fsSrc, src, fsDst, dst, nil)
+}
+
+// CopyWithOptions copies a file or directory from one file system to another recursively using the given copy options.
+func CopyWithOptions(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string, options *fs.CopyOptions) errors.Error {
 	if !fsSrc.CanRead() {
 		return fs.ErrNotSupported.Msg("Source file system does not support reading").Make()
 	}
@@ -23,7 +26,7 @@ func Copy(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string) er
 		return err
 	}
 	if isFile {
-		return copyFile(fsSrc, src, fsDst, dst)
+		return CopyFileWithOptions(fsSrc, src, fsDst, dst, options)
 	}
 
 	isDir, err := fsSrc.IsDir(src)
@@ -31,7 +34,7 @@ func Copy(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string) er
 		return err
 	}
 	if isDir {
-		return copyDir(fsSrc, src, fsDst, dst)
+		return CopyDirWithOptions(fsSrc, src, fsDst, dst, options)
 	}
 
 	return fs.ErrNotExists.Args(src).Make()
@@ -39,6 +42,11 @@ func Copy(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string) er
 
 // CopyFile copies a file from one file system to another.
 func CopyFile(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string) errors.Error {
+	return CopyFileWithOptions(fsSrc, src, fsDst, dst, nil)
+}
+
+// CopyFileWithOptions copies a file from one file system to another using the given copy options.
+func CopyFileWithOptions(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string, options *fs.CopyOptions) errors.Error {
 	if !fsSrc.CanRead() {
 		return fs.ErrNotSupported.Msg("Source file system does not support reading").Make()
 	}
@@ -46,31 +54,37 @@ func CopyFile(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string
 		return fs.ErrNotSupported.Msg("Destination file system does not support writing").Make()
 	}
 
-	return copyFile(fsSrc, src, fsDst, dst)
-}
-
-func copyFile(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string) errors.Error {
-	fSrc, err := fsSrc.Open(src)
-	if err != nil {
-		return err
+	if options == nil {
+		options = &fs.CopyOptions{}
 	}
-	defer fSrc.Close()
 
-	fDst, err := fsDst.CreateFile(dst)
-	if err != nil {
-		return err
+	if options.CheckFreeSpace {
+		fi, err := fsSrc.Stat(src)
+		if err != nil {
+			return err
+		}
+		if err := fsDst.EnsureFreeSpace(path.Dir(dst), uint64(fi.Size())); err != nil {
+			return err
+		}
 	}
-	defer fDst.Close()
 
-	if _, err := io.Copy(fDst, fSrc); err != nil {
-		return fs.Err.Msg("Failed to copy data").Make().Cause(err)
-	}
+	return copyFile(fsSrc, src, fsDst, dst, options)
+}
 
-	return nil
+func copyFile(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string, options *fs.CopyOptions) errors.Error {
+	if err := fs.CopyFileContent(fsSrc, src, fsDst, dst, options); err != nil {
+		return err
+	}
+	return fs.CopyMetadata(fsSrc, src, fsDst, dst, options)
 }
 
 // CopyDir copies a directory recursively from one file system to another.
 func CopyDir(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string) errors.Error {
+	return CopyDirWithOptions(fsSrc, src, fsDst, dst, nil)
+}
+
+// CopyDirWithOptions copies a directory recursively from one file system to another using the given copy options.
+func CopyDirWithOptions(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string, options *fs.CopyOptions) errors.Error {
 	if !fsSrc.CanRead() {
 		return fs.ErrNotSupported.Msg("Source file system does not support reading").Make()
 	}
@@ -78,16 +92,34 @@ func CopyDir(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string)
 		return fs.ErrNotSupported.Msg("Destination file system does not support writing").Make()
 	}
 
-	return copyDir(fsSrc, src, fsDst, dst)
+	if options == nil {
+		options = &fs.CopyOptions{}
+	}
+
+	if options.CheckFreeSpace {
+		if err := ensureFreeSpaceForDir(fsSrc, src, fsDst, path.Dir(dst)); err != nil {
+			return err
+		}
+	}
+
+	return copyDir(fsSrc, src, fsDst, dst, options)
 }
 
-func copyDir(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string) errors.Error {
+func copyDir(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string, options *fs.CopyOptions) errors.Error {
 	fsDst.CreateDirectory(dst)
-	return copyAll(fsSrc, src, fsDst, dst)
+	if err := fs.CopyTree(fsSrc, src, fsDst, dst, options); err != nil {
+		return err
+	}
+	return fs.CopyMetadata(fsSrc, src, fsDst, dst, options)
 }
 
 // CopyAll copies the content of a directory to another directory recursively.
 func CopyAll(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string) errors.Error {
+	return CopyAllWithOptions(fsSrc, src, fsDst, dst, nil)
+}
+
+// CopyAllWithOptions copies the content of a directory to another directory recursively using the given copy options.
+func CopyAllWithOptions(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string, options *fs.CopyOptions) errors.Error {
 	if !fsSrc.CanRead() {
 		return fs.ErrNotSupported.Msg("Source file system does not support reading").Make()
 	}
@@ -95,26 +127,23 @@ func CopyAll(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string)
 		return fs.ErrNotSupported.Msg("Destination file system does not support writing").Make()
 	}
 
-	return copyAll(fsSrc, src, fsDst, dst)
-}
-
-func copyAll(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string) errors.Error {
-	files, err := fsSrc.ReadDir(src)
-	if err != nil {
-		return err
+	if options == nil {
+		options = &fs.CopyOptions{}
 	}
 
-	for _, f := range files {
-		if f.IsDir() {
-			if err := copyDir(fsSrc, path.Join(src, f.Name()), fsDst, path.Join(dst, f.Name())); err != nil {
-				return err
-			}
-		} else {
-			if err := CopyFile(fsSrc, path.Join(src, f.Name()), fsDst, path.Join(dst, f.Name())); err != nil {
-				return err
-			}
+	if options.CheckFreeSpace {
+		if err := ensureFreeSpaceForDir(fsSrc, src, fsDst, dst); err != nil {
+			return err
 		}
 	}
 
-	return nil
+	return fs.CopyTree(fsSrc, src, fsDst, dst, options)
+}
+
+func ensureFreeSpaceForDir(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string) errors.Error {
+	usage, err := fsSrc.DiskUsage(src, &fs.DiskUsageOptions{LargestFilesCount: -1})
+	if err != nil {
+		return err
+	}
+	return fsDst.EnsureFreeSpace(dst, uint64(usage.TotalBytes))
 }
@@ -1,11 +1,15 @@
 package interop
 
 import (
+	"os"
 	"testing"
+	"time"
 
 	"github.com/sbreitf1/fs"
+	"github.com/sbreitf1/fs/path"
 
 	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestCopy(t *testing.T) {
@@ -60,3 +64,114 @@ func testCopy(t *testing.T, fs1, fs2 *fs.FileSystem) {
 		assertIsDir(t, fs2, "/test")
 	})
 }
+
+func TestCopyCheckFreeSpace(t *testing.T) {
+	fs.WithTempDir("fs-test-", func(tmpDir1 string) errors.Error {
+		return fs.WithTempDir("fs-test-", func(tmpDir2 string) errors.Error {
+			fs1 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir1})
+			fs2 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir2})
+			prepareDir(t, fs1)
+			errors.AssertNil(t, CopyWithOptions(fs1, "/foo", fs2, "/nice", &fs.CopyOptions{CheckFreeSpace: true}))
+			assertFileContent(t, fs2, "/nice/bar/hello/blub.txt", "bar2")
+			errors.AssertNil(t, CopyFileWithOptions(fs1, "/foo/test.txt", fs2, "/out.txt", &fs.CopyOptions{CheckFreeSpace: true}))
+			assertFileContent(t, fs2, "/out.txt", "foo1")
+			return nil
+		})
+	})
+}
+
+func TestCopyVerify(t *testing.T) {
+	fs.WithTempDir("fs-test-", func(tmpDir1 string) errors.Error {
+		return fs.WithTempDir("fs-test-", func(tmpDir2 string) errors.Error {
+			fs1 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir1})
+			fs2 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir2})
+			prepareDir(t, fs1)
+			errors.AssertNil(t, CopyWithOptions(fs1, "/foo", fs2, "/nice", &fs.CopyOptions{Verify: fs.HashSHA256}))
+			assertFileContent(t, fs2, "/nice/bar/hello/blub.txt", "bar2")
+
+			fsCorrupt := fs.NewWithDriver(&corruptingDriver{&fs.LocalDriver{Root: tmpDir2}})
+			errors.Assert(t, fs.ErrChecksumMismatch, CopyWithOptions(fs1, "/foo", fsCorrupt, "/corrupt", &fs.CopyOptions{Verify: fs.HashSHA256}))
+			errors.Assert(t, fs.ErrChecksumMismatch, CopyFileWithOptions(fs1, "/foo/test.txt", fsCorrupt, "/corrupt.txt", &fs.CopyOptions{Verify: fs.HashMD5}))
+			return nil
+		})
+	})
+}
+
+func TestCopyCloseError(t *testing.T) {
+	fs.WithTempDir("fs-test-", func(tmpDir1 string) errors.Error {
+		return fs.WithTempDir("fs-test-", func(tmpDir2 string) errors.Error {
+			fs1 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir1})
+			fs2 := fs.NewWithDriver(&failingCloseDriver{&fs.LocalDriver{Root: tmpDir2}})
+			prepareDir(t, fs1)
+			errors.Assert(t, fs.Err, CopyFile(fs1, "/foo/test.txt", fs2, "/out.txt"))
+			errors.Assert(t, fs.Err, Copy(fs1, "/foo", fs2, "/nice"))
+			errors.Assert(t, fs.Err, CopyAll(fs1, "/foo", fs2, "/"))
+			return nil
+		})
+	})
+}
+
+func TestCopyParallel(t *testing.T) {
+	fs.WithTempDir("fs-test-", func(tmpDir1 string) errors.Error {
+		return fs.WithTempDir("fs-test-", func(tmpDir2 string) errors.Error {
+			fs1 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir1})
+			fs2 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir2})
+			prepareDir(t, fs1)
+
+			errors.AssertNil(t, CopyDirWithOptions(fs1, "/foo", fs2, "/nice", &fs.CopyOptions{Parallelism: 4}))
+			assertFileContent(t, fs2, "/nice/test.txt", "foo1")
+			assertFileContent(t, fs2, "/nice/bar/hello/blub.txt", "bar2")
+			assertIsDir(t, fs2, "/nice/test")
+
+			fs3 := fs.NewWithDriver(&failingCloseDriver{&fs.LocalDriver{Root: tmpDir2}})
+			errors.Assert(t, fs.ErrCopyFailed, CopyAllWithOptions(fs1, "/foo", fs3, "/", &fs.CopyOptions{Parallelism: 4}))
+			return nil
+		})
+	})
+}
+
+func TestCopyMetadata(t *testing.T) {
+	fs.WithTempDir("fs-test-", func(tmpDir1 string) errors.Error {
+		return fs.WithTempDir("fs-test-", func(tmpDir2 string) errors.Error {
+			fs1 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir1})
+			fs2 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir2})
+			prepareDir(t, fs1)
+			modTime := time.Date(2020, 5, 17, 13, 37, 42, 0, time.UTC)
+			errors.AssertNil(t, os.Chmod(path.Join(tmpDir1, "/foo/bar/hello/blub.txt"), 0640))
+			errors.AssertNil(t, os.Chtimes(path.Join(tmpDir1, "/foo/bar/hello/blub.txt"), modTime, modTime))
+			errors.AssertNil(t, os.Chtimes(path.Join(tmpDir1, "/foo/bar"), modTime, modTime))
+
+			errors.AssertNil(t, CopyDirWithOptions(fs1, "/foo", fs2, "/nice", &fs.CopyOptions{PreserveMode: true, PreserveModTime: true}))
+			fi, err := os.Stat(path.Join(tmpDir2, "/nice/bar/hello/blub.txt"))
+			errors.AssertNil(t, err)
+			assert.Equal(t, os.FileMode(0640), fi.Mode().Perm())
+			assert.True(t, fi.ModTime().Equal(modTime))
+			fi, err = os.Stat(path.Join(tmpDir2, "/nice/bar"))
+			errors.AssertNil(t, err)
+			assert.True(t, fi.ModTime().Equal(modTime))
+			return nil
+		})
+	})
+}
+
+func TestCopyResume(t *testing.T) {
+	fs.WithTempDir("fs-test-", func(tmpDir1 string) errors.Error {
+		return fs.WithTempDir("fs-test-", func(tmpDir2 string) errors.Error {
+			fs1 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir1})
+			fs2 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir2})
+			errors.AssertNil(t, fs1.WriteString("/src.txt", "0123456789"))
+
+			errors.AssertNil(t, fs2.WriteString("/dst.txt", "abcde"))
+			errors.AssertNil(t, CopyFileWithOptions(fs1, "/src.txt", fs2, "/dst.txt", &fs.CopyOptions{Resume: true}))
+			assertFileContent(t, fs2, "/dst.txt", "abcde56789")
+
+			errors.AssertNil(t, fs2.WriteString("/dst.txt", "01x34"))
+			errors.AssertNil(t, CopyFileWithOptions(fs1, "/src.txt", fs2, "/dst.txt", &fs.CopyOptions{Resume: true, ResumeVerify: fs.HashMD5, ResumeChunkSize: 2, Verify: fs.HashSHA1}))
+			assertFileContent(t, fs2, "/dst.txt", "0123456789")
+
+			errors.AssertNil(t, fs2.WriteString("/dst.txt", "abcde"))
+			errors.Assert(t, fs.ErrChecksumMismatch, CopyFileWithOptions(fs1, "/src.txt", fs2, "/dst.txt", &fs.CopyOptions{Resume: true, Verify: fs.HashSHA1}))
+			return nil
+		})
+	})
+}
@@ -0,0 +1,135 @@
+package interop
+
+import (
+	"github.com/sbreitf1/fs"
+	"github.com/sbreitf1/fs/path"
+
+	"github.com/sbreitf1/errors"
+)
+
+// DiffOptions can be used to specify the behavior of Diff.
+type DiffOptions struct {
+	// Compare denotes how files existing in both directories are compared. Files are modified when their modification times are not equal.
+	Compare CompareMode
+	// HashAlgorithm denotes the hash function used to compare file contents. Defaults to SHA-256.
+	HashAlgorithm fs.HashAlgorithm
+}
+
+// DiffType denotes the kind of difference between two directories.
+type DiffType int
+
+const (
+	// DiffAdded denotes an element that only exists in the second directory.
+	DiffAdded DiffType = iota
+	// DiffRemoved denotes an element that only exists in the first directory.
+	DiffRemoved
+	// DiffModified denotes a file that exists in both directories with different content.
+	DiffModified
+	// DiffTypeChanged denotes an element that is a file in one directory and a directory in the other one.
+	DiffTypeChanged
+)
+
+// String returns a human readable representation of the difference type.
+func (t DiffType) String() string {
+	switch t {
+	case DiffAdded:
+		return "added"
+	case DiffRemoved:
+		return "removed"
+	case DiffModified:
+		return "modified"
+	case DiffTypeChanged:
+		return "type-changed"
+	default:
+		return "unknown"
+	}
+}
+
+// DiffEntry describes a single difference between two directories.
+type DiffEntry struct {
+	Type DiffType
+	// Path is relative to the compared directories.
+	Path string
+	// IsDir denotes whether the element is a directory in the first directory, or in the second directory for added elements.
+	IsDir bool
+}
+
+// Diff compares two directories recursively and returns all differences ordered by path. Content of added and removed directories is not listed separately.
+func Diff(fsA *fs.FileSystem, a string, fsB *fs.FileSystem, b string, options *DiffOptions) ([]DiffEntry, errors.Error) {
+	if !fsA.CanNavigate() || !fsB.CanNavigate() {
+		return nil, fs.ErrNotSupported.Msg("Both file systems must support navigation").Make()
+	}
+
+	if options == nil {
+		options = &DiffOptions{}
+	}
+	if options.Compare == CompareChecksum && (!fsA.CanRead() || !fsB.CanRead()) {
+		return nil, fs.ErrNotSupported.Msg("Both file systems must support reading to compare checksums").Make()
+	}
+
+	entries := make([]DiffEntry, 0)
+	if err := diffDir(fsA, a, fsB, b, "", options, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func diffDir(fsA *fs.FileSystem, a string, fsB *fs.FileSystem, b string, rel string, options *DiffOptions, entries *[]DiffEntry) errors.Error {
+	filesA, err := fsA.ReadDir(path.Join(a, rel))
+	if err != nil {
+		return err
+	}
+	fs.Sort(filesA, fs.OrderLexicographicAsc)
+
+	filesB, err := fsB.ReadDir(path.Join(b, rel))
+	if err != nil {
+		return err
+	}
+	fs.Sort(filesB, fs.OrderLexicographicAsc)
+
+	i, j := 0, 0
+	for i < len(filesA) || j < len(filesB) {
+		var order int
+		if i >= len(filesA) {
+			order = 1
+		} else if j >= len(filesB) {
+			order = -1
+		} else {
+			order = fs.OrderLexicographicAsc(filesA[i], filesB[j])
+		}
+
+		if order < 0 {
+			*entries = append(*entries, DiffEntry{DiffRemoved, path.Join(rel, filesA[i].Name()), filesA[i].IsDir()})
+			i++
+			continue
+		}
+		if order > 0 {
+			*entries = append(*entries, DiffEntry{DiffAdded, path.Join(rel, filesB[j].Name()), filesB[j].IsDir()})
+			j++
+			continue
+		}
+
+		fA, fB := filesA[i], filesB[j]
+		i++
+		j++
+		fRel := path.Join(rel, fA.Name())
+
+		if fA.IsDir() != fB.IsDir() {
+			*entries = append(*entries, DiffEntry{DiffTypeChanged, fRel, fA.IsDir()})
+		} else if fA.IsDir() {
+			if err := diffDir(fsA, a, fsB, b, fRel, options, entries); err != nil {
+				return err
+			}
+		} else {
+			differ, err := filesDiffer(fsA, path.Join(a, fRel), fA, fsB, path.Join(b, fRel), fB, options.Compare, options.HashAlgorithm)
+			if err != nil {
+				return err
+			}
+			if differ {
+				*entries = append(*entries, DiffEntry{DiffModified, fRel, false})
+			}
+		}
+	}
+
+	return nil
+}
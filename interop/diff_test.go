@@ -0,0 +1,105 @@
+package interop
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/fs"
+	"github.com/sbreitf1/fs/path"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	fs.WithTempDir("fs-test-", func(tmpDir1 string) errors.Error {
+		return fs.WithTempDir("fs-test-", func(tmpDir2 string) errors.Error {
+			fs1 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir1})
+			fs2 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir2})
+			testDiff(t, fs1, fs2, tmpDir1, tmpDir2)
+			return nil
+		})
+	})
+}
+
+func TestDiffWithoutModTime(t *testing.T) {
+	fs.WithTempDir("fs-test-", func(tmpDir1 string) errors.Error {
+		return fs.WithTempDir("fs-test-", func(tmpDir2 string) errors.Error {
+			fs1 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir1})
+			fs2 := fs.NewWithDriver(&noModTimeDriver{&fs.LocalDriver{Root: tmpDir2}})
+			prepareDir(t, fs1)
+			errors.AssertNil(t, CopyDir(fs1, "/foo", fs2, "/foo"))
+			assertDiff(t, fs1, fs2, &DiffOptions{Compare: CompareModTime}, []DiffEntry{})
+
+			// files of the same size are compared by content
+			errors.AssertNil(t, fs2.WriteString("/foo/test.txt", "foo2"))
+			assertDiff(t, fs1, fs2, &DiffOptions{Compare: CompareModTime}, []DiffEntry{{DiffModified, "test.txt", false}})
+			assertDiff(t, fs1, fs2, nil, []DiffEntry{{DiffModified, "test.txt", false}})
+			return nil
+		})
+	})
+}
+
+func testDiff(t *testing.T, fs1, fs2 *fs.FileSystem, rootDir1, rootDir2 string) {
+	prepareDir(t, fs1)
+	errors.AssertNil(t, CopyDir(fs1, "/foo", fs2, "/foo"))
+
+	t.Run("TestDiffEqual", func(t *testing.T) {
+		assertDiff(t, fs1, fs2, &DiffOptions{Compare: CompareChecksum}, []DiffEntry{})
+		assertDiff(t, fs1, fs2, &DiffOptions{Compare: CompareSize}, []DiffEntry{})
+	})
+
+	t.Run("TestDiffModTime", func(t *testing.T) {
+		modTime := time.Now().Add(-time.Hour)
+		for _, p := range []string{"/foo/test.txt", "/foo/bar/hello/blub.txt"} {
+			assert.NoError(t, os.Chtimes(path.Join(rootDir1, p), modTime, modTime))
+			assert.NoError(t, os.Chtimes(path.Join(rootDir2, p), modTime, modTime))
+		}
+		assertDiff(t, fs1, fs2, nil, []DiffEntry{})
+
+		assert.NoError(t, os.Chtimes(path.Join(rootDir2, "/foo/test.txt"), modTime, modTime.Add(time.Second)))
+		assertDiff(t, fs1, fs2, nil, []DiffEntry{{DiffModified, "test.txt", false}})
+		assertDiff(t, fs1, fs2, &DiffOptions{Compare: CompareModTime}, []DiffEntry{{DiffModified, "test.txt", false}})
+		assertDiff(t, fs1, fs2, &DiffOptions{Compare: CompareSize}, []DiffEntry{})
+	})
+
+	t.Run("TestDiffChanges", func(t *testing.T) {
+		errors.AssertNil(t, fs2.WriteString("/foo/test.txt", "foo2"))
+		errors.AssertNil(t, fs2.WriteString("/foo/bar/hello/blub.txt", "longer content"))
+		errors.AssertNil(t, fs2.WriteString("/foo/added.txt", "added"))
+		errors.AssertNil(t, fs2.CreateDirectory("/foo/addeddir/sub"))
+		errors.AssertNil(t, fs2.DeleteDirectory("/foo/test", false))
+		errors.AssertNil(t, fs2.WriteString("/foo/test", "now a file"))
+		errors.AssertNil(t, fs1.CreateDirectory("/foo/removed"))
+
+		assertDiff(t, fs1, fs2, &DiffOptions{Compare: CompareSize}, []DiffEntry{
+			{DiffAdded, "added.txt", false},
+			{DiffAdded, "addeddir", true},
+			{DiffModified, "bar/hello/blub.txt", false},
+			{DiffRemoved, "removed", true},
+			{DiffTypeChanged, "test", true},
+		})
+		assertDiff(t, fs1, fs2, &DiffOptions{Compare: CompareChecksum, HashAlgorithm: fs.HashMD5}, []DiffEntry{
+			{DiffAdded, "added.txt", false},
+			{DiffAdded, "addeddir", true},
+			{DiffModified, "bar/hello/blub.txt", false},
+			{DiffRemoved, "removed", true},
+			{DiffTypeChanged, "test", true},
+			{DiffModified, "test.txt", false},
+		})
+	})
+
+	t.Run("TestDiffNonExistent", func(t *testing.T) {
+		_, err := Diff(fs1, "/foo", fs2, "/nonexistingdir", nil)
+		errors.Assert(t, fs.ErrDirectoryNotExists, err)
+	})
+}
+
+func assertDiff(t *testing.T, fs1, fs2 *fs.FileSystem, options *DiffOptions, expected []DiffEntry) bool {
+	entries, err := Diff(fs1, "/foo", fs2, "/foo", options)
+	if errors.AssertNil(t, err) {
+		return assert.Equal(t, expected, entries)
+	}
+	return false
+}
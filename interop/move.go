@@ -2,11 +2,36 @@ package interop
 
 import (
 	"github.com/sbreitf1/fs"
+	"github.com/sbreitf1/fs/path"
 
 	"github.com/sbreitf1/errors"
 )
 
-// Move moves a file or directory from one file system to another recursively.
+var (
+	// ErrSourceChanged occurs when elements have been kept in the source, because they were changed or added while moving.
+	ErrSourceChanged = errors.New("%d elements have been changed while moving and were kept in the source")
+)
+
+// MoveReport lists the elements processed by a move operation.
+type MoveReport struct {
+	// Moved contains the source paths of all files and directories that have been copied and removed from the source.
+	Moved []string
+	// Skipped contains the source paths of all files and directories that have been copied but kept in the source, because they were changed or new elements were added while moving.
+	Skipped []string
+}
+
+func newMoveReport() *MoveReport {
+	return &MoveReport{Moved: make([]string, 0), Skipped: make([]string, 0)}
+}
+
+func (r *MoveReport) err() errors.Error {
+	if len(r.Skipped) > 0 {
+		return ErrSourceChanged.Args(len(r.Skipped)).Make()
+	}
+	return nil
+}
+
+// Move moves a file or directory from one file system to another recursively. Returns ErrSourceChanged if elements have been changed while moving, which are then kept in the source in addition to the copy in the destination.
 func Move(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string) errors.Error {
 	if !fsSrc.CanWrite() {
 		return fs.ErrNotSupported.Msg("Source file system does not support writing").Make()
@@ -20,7 +45,11 @@ func Move(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string) er
 		return err
 	}
 	if isFile {
-		return moveFile(fsSrc, src, fsDst, dst)
+		report := newMoveReport()
+		if err := moveFile(fsSrc, src, fsDst, dst, report); err != nil {
+			return err
+		}
+		return report.err()
 	}
 
 	isDir, err := fsSrc.IsDir(src)
@@ -28,13 +57,17 @@ func Move(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string) er
 		return err
 	}
 	if isDir {
-		return moveDir(fsSrc, src, fsDst, dst)
+		report := newMoveReport()
+		if err := moveDir(fsSrc, src, fsDst, dst, report); err != nil {
+			return err
+		}
+		return report.err()
 	}
 
 	return fs.ErrNotExists.Args(src).Make()
 }
 
-// MoveFile moves a file from one file system to another.
+// MoveFile moves a file from one file system to another. The source file is only deleted when it has not been changed while copying, otherwise ErrSourceChanged is returned.
 func MoveFile(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string) errors.Error {
 	if !fsSrc.CanWrite() {
 		return fs.ErrNotSupported.Msg("Source file system does not support writing").Make()
@@ -43,18 +76,47 @@ func MoveFile(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string
 		return fs.ErrNotSupported.Msg("Destination file system does not support writing").Make()
 	}
 
-	return moveFile(fsSrc, src, fsDst, dst)
+	report := newMoveReport()
+	if err := moveFile(fsSrc, src, fsDst, dst, report); err != nil {
+		return err
+	}
+	return report.err()
 }
 
-func moveFile(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string) errors.Error {
-	if err := copyFile(fsSrc, src, fsDst, dst); err != nil {
+func moveFile(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string, report *MoveReport) errors.Error {
+	before, err := fsSrc.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if err := copyFile(fsSrc, src, fsDst, dst, &fs.CopyOptions{}); err != nil {
+		return err
+	}
+
+	after, err := fsSrc.Stat(src)
+	if err != nil {
+		return err
+	}
+	if fileChanged(before, after) {
+		report.Skipped = append(report.Skipped, src)
+		return nil
+	}
+
+	if err := fsSrc.DeleteFile(src); err != nil {
 		return err
 	}
+	report.Moved = append(report.Moved, src)
+	return nil
+}
 
-	return fsSrc.DeleteFile(src)
+func fileChanged(before, after fs.FileInfo) bool {
+	if before.Size() != after.Size() {
+		return true
+	}
+	return !modTimesEqual(before, after)
 }
 
-// MoveDir moves a directory recursively from one file system to another.
+// MoveDir moves a directory recursively from one file system to another. Every element is deleted from the source directly after it has been copied. Returns ErrSourceChanged if elements have been changed while moving, which are then kept in the source in addition to the copy in the destination.
 func MoveDir(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string) errors.Error {
 	if !fsSrc.CanWrite() {
 		return fs.ErrNotSupported.Msg("Source file system does not support writing").Make()
@@ -63,29 +125,81 @@ func MoveDir(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string)
 		return fs.ErrNotSupported.Msg("Destination file system does not support writing").Make()
 	}
 
-	return moveDir(fsSrc, src, fsDst, dst)
+	report := newMoveReport()
+	if err := moveDir(fsSrc, src, fsDst, dst, report); err != nil {
+		return err
+	}
+	return report.err()
 }
 
-func moveDir(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string) errors.Error {
-	if err := copyDir(fsSrc, src, fsDst, dst); err != nil {
+func moveDir(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string, report *MoveReport) errors.Error {
+	if err := fsDst.CreateDirectory(dst); err != nil {
+		return err
+	}
+
+	if err := moveAll(fsSrc, src, fsDst, dst, report); err != nil {
 		return err
 	}
 
-	return fsSrc.DeleteDirectory(src, true)
+	// only remove directory when no new elements have been added while moving
+	files, err := fsSrc.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	if len(files) > 0 {
+		report.Skipped = append(report.Skipped, src)
+		return nil
+	}
+
+	if err := fsSrc.DeleteDirectory(src, false); err != nil {
+		return err
+	}
+	report.Moved = append(report.Moved, src)
+	return nil
 }
 
-// MoveAll moves the content of a directory to another directory recursively.
+// MoveAll moves the content of a directory to another directory recursively. Every element is deleted from the source directly after it has been copied, so a failed move can be resumed by calling MoveAll again. Returns ErrSourceChanged if elements have been changed while moving, which are then kept in the source in addition to the copy in the destination.
 func MoveAll(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string) errors.Error {
+	report, err := MoveAllWithReport(fsSrc, src, fsDst, dst)
+	if err != nil {
+		return err
+	}
+	return report.err()
+}
+
+// MoveAllWithReport moves the content of a directory to another directory recursively and returns all moved and skipped elements. Elements that are changed or added while moving are kept in the source and reported as skipped.
+//
+// The returned report contains all elements processed until an error occured.
+func MoveAllWithReport(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string) (*MoveReport, errors.Error) {
 	if !fsSrc.CanWrite() {
-		return fs.ErrNotSupported.Msg("Source file system does not support writing").Make()
+		return nil, fs.ErrNotSupported.Msg("Source file system does not support writing").Make()
 	}
 	if !fsDst.CanWrite() {
-		return fs.ErrNotSupported.Msg("Destination file system does not support writing").Make()
+		return nil, fs.ErrNotSupported.Msg("Destination file system does not support writing").Make()
 	}
 
-	if err := copyAll(fsSrc, src, fsDst, dst); err != nil {
+	report := newMoveReport()
+	return report, moveAll(fsSrc, src, fsDst, dst, report)
+}
+
+func moveAll(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string, report *MoveReport) errors.Error {
+	files, err := fsSrc.ReadDir(src)
+	if err != nil {
 		return err
 	}
+	fs.Sort(files, fs.OrderLexicographicAsc)
+
+	for _, f := range files {
+		if f.IsDir() {
+			if err := moveDir(fsSrc, path.Join(src, f.Name()), fsDst, path.Join(dst, f.Name()), report); err != nil {
+				return err
+			}
+		} else {
+			if err := moveFile(fsSrc, path.Join(src, f.Name()), fsDst, path.Join(dst, f.Name()), report); err != nil {
+				return err
+			}
+		}
+	}
 
-	return fsSrc.CleanDir(src)
+	return nil
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/sbreitf1/fs"
 
 	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestMove(t *testing.T) {
@@ -64,3 +65,117 @@ func testMove(t *testing.T, fs1, fs2 *fs.FileSystem) {
 		assertIsDir(t, fs2, "/test")
 	})
 }
+
+func TestMoveCloseError(t *testing.T) {
+	fs.WithTempDir("fs-test-", func(tmpDir1 string) errors.Error {
+		return fs.WithTempDir("fs-test-", func(tmpDir2 string) errors.Error {
+			fs1 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir1})
+			fs2 := fs.NewWithDriver(&failingCloseDriver{&fs.LocalDriver{Root: tmpDir2}})
+			prepareDir(t, fs1)
+			errors.Assert(t, fs.Err, MoveFile(fs1, "/foo/test.txt", fs2, "/out.txt"))
+			// source must not be deleted when the copy failed
+			assertFileContent(t, fs1, "/foo/test.txt", "foo1")
+			errors.Assert(t, fs.Err, MoveDir(fs1, "/foo", fs2, "/nice"))
+			assertIsDir(t, fs1, "/foo/bar/hello")
+			return nil
+		})
+	})
+}
+
+func TestMoveAllWithReport(t *testing.T) {
+	fs.WithTempDir("fs-test-", func(tmpDir1 string) errors.Error {
+		return fs.WithTempDir("fs-test-", func(tmpDir2 string) errors.Error {
+			fs1 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir1})
+			fs2 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir2})
+			prepareDir(t, fs1)
+
+			report, err := MoveAllWithReport(fs1, "/foo", fs2, "/")
+			errors.AssertNil(t, err)
+			assert.Equal(t, []string{"/foo/bar/hello/blub.txt", "/foo/bar/hello", "/foo/bar", "/foo/test", "/foo/test.txt"}, report.Moved)
+			assert.Empty(t, report.Skipped)
+			return nil
+		})
+	})
+}
+
+func TestMoveAllChangedSource(t *testing.T) {
+	fs.WithTempDir("fs-test-", func(tmpDir1 string) errors.Error {
+		return fs.WithTempDir("fs-test-", func(tmpDir2 string) errors.Error {
+			fs1 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir1})
+			driver := &interferingDriver{LocalDriver: &fs.LocalDriver{Root: tmpDir2}}
+			fs2 := fs.NewWithDriver(driver)
+			prepareDir(t, fs1)
+
+			driver.onWrite = func(path string) {
+				switch path {
+				case "/bar/hello/blub.txt":
+					// new file appears in a directory that is currently moved
+					errors.AssertNil(t, fs1.WriteString("/foo/bar/new.txt", "new"))
+				case "/test.txt":
+					// file is modified while copying
+					errors.AssertNil(t, fs1.WriteString("/foo/test.txt", "foo1 changed"))
+				}
+			}
+
+			report, err := MoveAllWithReport(fs1, "/foo", fs2, "/")
+			errors.AssertNil(t, err)
+			assert.Equal(t, []string{"/foo/bar/hello/blub.txt", "/foo/bar/hello", "/foo/test"}, report.Moved)
+			assert.Equal(t, []string{"/foo/bar", "/foo/test.txt"}, report.Skipped)
+			assertFileContent(t, fs1, "/foo/bar/new.txt", "new")
+			assertFileContent(t, fs1, "/foo/test.txt", "foo1 changed")
+			assertNotExists(t, fs1, "/foo/bar/hello")
+
+			// resume moving the remaining elements
+			driver.onWrite = nil
+			errors.AssertNil(t, MoveAll(fs1, "/foo", fs2, "/"))
+			assertNotExists(t, fs1, "/foo/bar")
+			assertNotExists(t, fs1, "/foo/test.txt")
+			assertFileContent(t, fs2, "/bar/new.txt", "new")
+			assertFileContent(t, fs2, "/test.txt", "foo1 changed")
+			return nil
+		})
+	})
+}
+
+func TestMoveAllChangedSourceError(t *testing.T) {
+	fs.WithTempDir("fs-test-", func(tmpDir1 string) errors.Error {
+		return fs.WithTempDir("fs-test-", func(tmpDir2 string) errors.Error {
+			fs1 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir1})
+			fs2 := fs.NewWithDriver(&interferingDriver{LocalDriver: &fs.LocalDriver{Root: tmpDir2}, onWrite: func(path string) {
+				errors.AssertNil(t, fs1.WriteString("/foo/new.txt", "new"))
+			}})
+			prepareDir(t, fs1)
+
+			errors.Assert(t, ErrSourceChanged, MoveDir(fs1, "/foo", fs2, "/nice"))
+			assertFileContent(t, fs1, "/foo/new.txt", "new")
+			assertNotExists(t, fs1, "/foo/test.txt")
+			return nil
+		})
+	})
+}
+
+func TestMoveAllPartialFailure(t *testing.T) {
+	fs.WithTempDir("fs-test-", func(tmpDir1 string) errors.Error {
+		return fs.WithTempDir("fs-test-", func(tmpDir2 string) errors.Error {
+			fs1 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir1})
+			driver := fs.NewFaultDriver(&fs.LocalDriver{Root: tmpDir2}, 0)
+			fs2 := fs.NewWithDriver(driver)
+			prepareDir(t, fs1)
+			driver.AddFault(fs.Fault{Call: fs.CallOpenFile, Path: "/test.txt"})
+
+			report, err := MoveAllWithReport(fs1, "/foo", fs2, "/")
+			errors.Assert(t, fs.ErrInjectedFault, err)
+			assert.Equal(t, []string{"/foo/bar/hello/blub.txt", "/foo/bar/hello", "/foo/bar", "/foo/test"}, report.Moved)
+			assertFileContent(t, fs1, "/foo/test.txt", "foo1")
+			assertNotExists(t, fs2, "/test.txt")
+
+			// resume moving the remaining elements
+			driver.ClearFaults()
+			errors.AssertNil(t, MoveAll(fs1, "/foo", fs2, "/"))
+			assertNotExists(t, fs1, "/foo/test.txt")
+			assertFileContent(t, fs2, "/test.txt", "foo1")
+			assertFileContent(t, fs2, "/bar/hello/blub.txt", "bar2")
+			return nil
+		})
+	})
+}
@@ -0,0 +1,76 @@
+package interop
+
+import (
+	"github.com/sbreitf1/fs"
+	"github.com/sbreitf1/fs/path"
+
+	"github.com/sbreitf1/errors"
+)
+
+// PlanMoveAll returns the operations performed by MoveAll without applying them. Directories are created on the destination file system, files are copied from the source to the destination file system and delete operations are performed on the source file system.
+func PlanMoveAll(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string) (*fs.Plan, errors.Error) {
+	if !fsSrc.CanNavigate() {
+		return nil, fs.ErrNotSupported.Msg("Source file system does not support navigation").Make()
+	}
+
+	plan := &fs.Plan{Operations: make([]fs.Operation, 0)}
+	if err := planMoveAll(plan, fsSrc, src, dst); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func planMoveAll(plan *fs.Plan, fsSrc *fs.FileSystem, src string, dst string) errors.Error {
+	files, err := fsSrc.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	fs.Sort(files, fs.OrderLexicographicAsc)
+
+	for _, f := range files {
+		srcPath := path.Join(src, f.Name())
+		dstPath := path.Join(dst, f.Name())
+		if f.IsDir() {
+			plan.Add(fs.OpCreateDirectory, dstPath, "")
+			if err := planMoveAll(plan, fsSrc, srcPath, dstPath); err != nil {
+				return err
+			}
+			plan.Add(fs.OpDeleteDirectory, srcPath, "")
+		} else {
+			plan.Add(fs.OpCopyFile, srcPath, dstPath)
+			plan.Add(fs.OpDeleteFile, srcPath, "")
+		}
+	}
+
+	return nil
+}
+
+// ApplyPlan performs all operations of a plan between two file systems in the given order and stops at the first error. Directories are created on the destination file system, files are copied from the source to the destination file system and delete operations are performed on the source file system.
+func ApplyPlan(fsSrc *fs.FileSystem, fsDst *fs.FileSystem, plan *fs.Plan) errors.Error {
+	if !fsSrc.CanWrite() {
+		return fs.ErrNotSupported.Msg("Source file system does not support writing").Make()
+	}
+	if !fsDst.CanWrite() {
+		return fs.ErrNotSupported.Msg("Destination file system does not support writing").Make()
+	}
+
+	for _, op := range plan.Operations {
+		var err errors.Error
+		switch op.Type {
+		case fs.OpCreateDirectory:
+			err = fsDst.CreateDirectory(op.Path)
+		case fs.OpCopyFile:
+			err = copyFile(fsSrc, op.Path, fsDst, op.Target, &fs.CopyOptions{})
+		case fs.OpDeleteFile:
+			err = fsSrc.DeleteFile(op.Path)
+		case fs.OpDeleteDirectory:
+			err = fsSrc.DeleteDirectory(op.Path, false)
+		default:
+			err = fs.ErrNotSupported.Msg("Operation %s is not supported between file systems", op.Type).Make()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
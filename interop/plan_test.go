@@ -0,0 +1,49 @@
+package interop
+
+import (
+	"testing"
+
+	"github.com/sbreitf1/fs"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanMoveAll(t *testing.T) {
+	fs.WithTempDir("fs-test-", func(tmpDir1 string) errors.Error {
+		return fs.WithTempDir("fs-test-", func(tmpDir2 string) errors.Error {
+			fs1 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir1})
+			fs2 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir2})
+			prepareDir(t, fs1)
+
+			plan, err := PlanMoveAll(fs1, "/foo", fs2, "/")
+			errors.AssertNil(t, err)
+			assert.Equal(t, []fs.Operation{
+				{Type: fs.OpCreateDirectory, Path: "/bar"},
+				{Type: fs.OpCreateDirectory, Path: "/bar/hello"},
+				{Type: fs.OpCopyFile, Path: "/foo/bar/hello/blub.txt", Target: "/bar/hello/blub.txt"},
+				{Type: fs.OpDeleteFile, Path: "/foo/bar/hello/blub.txt"},
+				{Type: fs.OpDeleteDirectory, Path: "/foo/bar/hello"},
+				{Type: fs.OpDeleteDirectory, Path: "/foo/bar"},
+				{Type: fs.OpCreateDirectory, Path: "/test"},
+				{Type: fs.OpDeleteDirectory, Path: "/foo/test"},
+				{Type: fs.OpCopyFile, Path: "/foo/test.txt", Target: "/test.txt"},
+				{Type: fs.OpDeleteFile, Path: "/foo/test.txt"},
+			}, plan.Operations)
+			assertIsFile(t, fs1, "/foo/test.txt")
+			assertNotExists(t, fs2, "/bar")
+
+			errors.AssertNil(t, ApplyPlan(fs1, fs2, plan))
+			assertIsDir(t, fs1, "/foo")
+			assertNotExists(t, fs1, "/foo/bar")
+			assertNotExists(t, fs1, "/foo/test.txt")
+			assertFileContent(t, fs2, "/test.txt", "foo1")
+			assertFileContent(t, fs2, "/bar/hello/blub.txt", "bar2")
+			assertIsDir(t, fs2, "/test")
+
+			plan.Operations = []fs.Operation{{Type: fs.OpMoveFile, Path: "/a", Target: "/b"}}
+			errors.Assert(t, fs.ErrNotSupported, ApplyPlan(fs1, fs2, plan))
+			return nil
+		})
+	})
+}
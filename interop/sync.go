@@ -0,0 +1,231 @@
+package interop
+
+import (
+	"github.com/sbreitf1/fs"
+	"github.com/sbreitf1/fs/path"
+
+	"github.com/sbreitf1/errors"
+)
+
+// SyncOptions can be used to specify the behavior of Sync.
+type SyncOptions struct {
+	// Compare denotes how existing files are checked for changes. With modification times, a file is updated when the modification times of source and destination differ.
+	Compare CompareMode
+	// HashAlgorithm denotes the hash function used to compare file contents. Defaults to SHA-256.
+	HashAlgorithm fs.HashAlgorithm
+	// Delete causes files and directories to be removed from the destination when they do not exist in the source.
+	Delete bool
+	// DryRun only reports the actions that would be performed without changing the destination.
+	DryRun bool
+	// CopyOptions are used to copy new and changed files. Modification times are always preserved when files are compared by modification time, so that copied files are not considered changed on the next run. Defaults to preserving modification times.
+	CopyOptions *fs.CopyOptions
+}
+
+// SyncActionType denotes the type of change applied by Sync.
+type SyncActionType int
+
+const (
+	// SyncCreateDir denotes a directory that is created in the destination.
+	SyncCreateDir SyncActionType = iota
+	// SyncCopy denotes a new file that is copied to the destination.
+	SyncCopy
+	// SyncUpdate denotes an existing file in the destination that is overwritten.
+	SyncUpdate
+	// SyncDelete denotes a file or directory that is removed from the destination.
+	SyncDelete
+)
+
+// String returns a human readable representation of the action type.
+func (t SyncActionType) String() string {
+	switch t {
+	case SyncCreateDir:
+		return "create-dir"
+	case SyncCopy:
+		return "copy"
+	case SyncUpdate:
+		return "update"
+	case SyncDelete:
+		return "delete"
+	default:
+		return "unknown"
+	}
+}
+
+// SyncAction describes a single change applied by Sync.
+type SyncAction struct {
+	Type SyncActionType
+	// Path is relative to the synchronized directories. An empty path denotes the destination directory itself.
+	Path string
+	// IsDir is true when the action affects a directory.
+	IsDir bool
+}
+
+// SyncReport contains all actions in the order they have been applied by Sync.
+type SyncReport struct {
+	Actions []SyncAction
+}
+
+// Sync brings the destination directory in line with the source directory by copying new files, updating changed ones and optionally deleting extraneous files and directories.
+//
+// The returned report contains all actions performed until an error occured.
+func Sync(fsSrc *fs.FileSystem, src string, fsDst *fs.FileSystem, dst string, options *SyncOptions) (*SyncReport, errors.Error) {
+	if !fsSrc.CanRead() {
+		return nil, fs.ErrNotSupported.Msg("Source file system does not support reading").Make()
+	}
+	if !fsDst.CanWrite() {
+		return nil, fs.ErrNotSupported.Msg("Destination file system does not support writing").Make()
+	}
+
+	opts := SyncOptions{}
+	if options != nil {
+		opts = *options
+	}
+	if opts.CopyOptions == nil {
+		opts.CopyOptions = &fs.CopyOptions{PreserveModTime: true}
+	} else if opts.Compare == CompareSizeAndModTime || opts.Compare == CompareModTime {
+		copyOptions := *opts.CopyOptions
+		copyOptions.PreserveModTime = true
+		opts.CopyOptions = &copyOptions
+	}
+	s := &syncer{fsSrc, src, fsDst, dst, &opts, &SyncReport{Actions: make([]SyncAction, 0)}}
+
+	isDir, err := fsSrc.IsDir(src)
+	if err != nil {
+		return s.report, err
+	}
+	if !isDir {
+		return s.report, fs.ErrDirectoryNotExists.Args(src).Make()
+	}
+
+	dstExists, err := fsDst.IsDir(dst)
+	if err != nil {
+		return s.report, err
+	}
+	if !dstExists {
+		if err := s.apply(SyncCreateDir, "", true); err != nil {
+			return s.report, err
+		}
+	}
+
+	return s.report, s.syncDir("", dstExists)
+}
+
+type syncer struct {
+	fsSrc   *fs.FileSystem
+	src     string
+	fsDst   *fs.FileSystem
+	dst     string
+	options *SyncOptions
+	report  *SyncReport
+}
+
+func (s *syncer) syncDir(rel string, dstExists bool) errors.Error {
+	srcFiles, err := s.fsSrc.ReadDir(path.Join(s.src, rel))
+	if err != nil {
+		return err
+	}
+	fs.Sort(srcFiles, fs.OrderLexicographicAsc)
+
+	dstFiles := make([]fs.FileInfo, 0)
+	if dstExists {
+		dstFiles, err = s.fsDst.ReadDir(path.Join(s.dst, rel))
+		if err != nil {
+			return err
+		}
+		fs.Sort(dstFiles, fs.OrderLexicographicAsc)
+	}
+	dstMap := make(map[string]fs.FileInfo)
+	for _, f := range dstFiles {
+		dstMap[f.Name()] = f
+	}
+
+	for _, f := range srcFiles {
+		fRel := path.Join(rel, f.Name())
+		dstFile, exists := dstMap[f.Name()]
+
+		if exists && dstFile.IsDir() != f.IsDir() {
+			// type changed -> remove destination before creating the new element
+			if err := s.apply(SyncDelete, fRel, dstFile.IsDir()); err != nil {
+				return err
+			}
+			exists = false
+		}
+
+		if f.IsDir() {
+			if !exists {
+				if err := s.apply(SyncCreateDir, fRel, true); err != nil {
+					return err
+				}
+			}
+			if err := s.syncDir(fRel, exists || !s.options.DryRun); err != nil {
+				return err
+			}
+
+		} else if !exists {
+			if err := s.apply(SyncCopy, fRel, false); err != nil {
+				return err
+			}
+
+		} else {
+			changed, err := s.isChanged(fRel, f, dstFile)
+			if err != nil {
+				return err
+			}
+			if changed {
+				if err := s.apply(SyncUpdate, fRel, false); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if s.options.Delete {
+		srcMap := make(map[string]bool)
+		for _, f := range srcFiles {
+			srcMap[f.Name()] = true
+		}
+
+		for _, f := range dstFiles {
+			if !srcMap[f.Name()] {
+				if err := s.apply(SyncDelete, path.Join(rel, f.Name()), f.IsDir()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *syncer) isChanged(rel string, srcFile, dstFile fs.FileInfo) (bool, errors.Error) {
+	return filesDiffer(s.fsSrc, path.Join(s.src, rel), srcFile, s.fsDst, path.Join(s.dst, rel), dstFile, s.options.Compare, s.options.HashAlgorithm)
+}
+
+func (s *syncer) apply(actionType SyncActionType, rel string, isDir bool) errors.Error {
+	if !s.options.DryRun {
+		dstPath := path.Join(s.dst, rel)
+		switch actionType {
+		case SyncCreateDir:
+			if err := s.fsDst.CreateDirectory(dstPath); err != nil {
+				return err
+			}
+		case SyncCopy, SyncUpdate:
+			if err := copyFile(s.fsSrc, path.Join(s.src, rel), s.fsDst, dstPath, s.options.CopyOptions); err != nil {
+				return err
+			}
+		case SyncDelete:
+			if isDir {
+				if err := s.fsDst.DeleteDirectory(dstPath, true); err != nil {
+					return err
+				}
+			} else {
+				if err := s.fsDst.DeleteFile(dstPath); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	s.report.Actions = append(s.report.Actions, SyncAction{actionType, rel, isDir})
+	return nil
+}
@@ -0,0 +1,143 @@
+package interop
+
+import (
+	"testing"
+
+	"github.com/sbreitf1/fs"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSync(t *testing.T) {
+	fs.WithTempDir("fs-test-", func(tmpDir1 string) errors.Error {
+		return fs.WithTempDir("fs-test-", func(tmpDir2 string) errors.Error {
+			fs1 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir1})
+			fs2 := fs.NewWithDriver(&fs.LocalDriver{Root: tmpDir2})
+			testSync(t, fs1, fs2)
+			return nil
+		})
+	})
+}
+
+func testSync(t *testing.T, fs1, fs2 *fs.FileSystem) {
+	prepareDir(t, fs1)
+
+	t.Run("TestSyncDryRun", func(t *testing.T) {
+		report, err := Sync(fs1, "/foo", fs2, "/mirror", &SyncOptions{DryRun: true})
+		errors.AssertNil(t, err)
+		assertSyncActions(t, report, []SyncAction{
+			{SyncCreateDir, "", true},
+			{SyncCreateDir, "bar", true},
+			{SyncCreateDir, "bar/hello", true},
+			{SyncCopy, "bar/hello/blub.txt", false},
+			{SyncCreateDir, "test", true},
+			{SyncCopy, "test.txt", false},
+		})
+		assertNotExists(t, fs2, "/mirror")
+	})
+
+	t.Run("TestSyncInitial", func(t *testing.T) {
+		report, err := Sync(fs1, "/foo", fs2, "/mirror", nil)
+		errors.AssertNil(t, err)
+		assert.Len(t, report.Actions, 6)
+		assertFileContent(t, fs2, "/mirror/test.txt", "foo1")
+		assertFileContent(t, fs2, "/mirror/bar/hello/blub.txt", "bar2")
+		assertIsDir(t, fs2, "/mirror/test")
+	})
+
+	t.Run("TestSyncUnchanged", func(t *testing.T) {
+		report, err := Sync(fs1, "/foo", fs2, "/mirror", nil)
+		errors.AssertNil(t, err)
+		assertSyncActions(t, report, []SyncAction{})
+	})
+
+	t.Run("TestSyncModTime", func(t *testing.T) {
+		srcInfo, err := fs1.Stat("/foo/test.txt")
+		errors.AssertNil(t, err)
+		dstInfo, err := fs2.Stat("/mirror/test.txt")
+		errors.AssertNil(t, err)
+		assert.True(t, modTimesEqual(srcInfo, dstInfo))
+
+		// destination files that are newer than the source are updated as well
+		errors.AssertNil(t, fs2.WriteString("/mirror/test.txt", "foo2"))
+		report, err := Sync(fs1, "/foo", fs2, "/mirror", nil)
+		errors.AssertNil(t, err)
+		assertSyncActions(t, report, []SyncAction{{SyncUpdate, "test.txt", false}})
+		assertFileContent(t, fs2, "/mirror/test.txt", "foo1")
+	})
+
+	t.Run("TestSyncUpdate", func(t *testing.T) {
+		errors.AssertNil(t, fs1.WriteString("/foo/test.txt", "foo1 changed"))
+		errors.AssertNil(t, fs1.WriteString("/foo/test/new.txt", "new"))
+		report, err := Sync(fs1, "/foo", fs2, "/mirror", nil)
+		errors.AssertNil(t, err)
+		assertSyncActions(t, report, []SyncAction{
+			{SyncCopy, "test/new.txt", false},
+			{SyncUpdate, "test.txt", false},
+		})
+		assertFileContent(t, fs2, "/mirror/test.txt", "foo1 changed")
+		assertFileContent(t, fs2, "/mirror/test/new.txt", "new")
+	})
+
+	t.Run("TestSyncChecksum", func(t *testing.T) {
+		// same size but different content
+		errors.AssertNil(t, fs2.WriteString("/mirror/test/new.txt", "old"))
+
+		report, err := Sync(fs1, "/foo", fs2, "/mirror", &SyncOptions{Compare: CompareSize})
+		errors.AssertNil(t, err)
+		assertSyncActions(t, report, []SyncAction{})
+
+		report, err = Sync(fs1, "/foo", fs2, "/mirror", &SyncOptions{Compare: CompareChecksum})
+		errors.AssertNil(t, err)
+		assertSyncActions(t, report, []SyncAction{{SyncUpdate, "test/new.txt", false}})
+		assertFileContent(t, fs2, "/mirror/test/new.txt", "new")
+	})
+
+	t.Run("TestSyncDelete", func(t *testing.T) {
+		errors.AssertNil(t, fs2.WriteString("/mirror/extra.txt", "extra"))
+		errors.AssertNil(t, fs2.CreateDirectory("/mirror/bar/extra"))
+
+		report, err := Sync(fs1, "/foo", fs2, "/mirror", nil)
+		errors.AssertNil(t, err)
+		assertSyncActions(t, report, []SyncAction{})
+
+		report, err = Sync(fs1, "/foo", fs2, "/mirror", &SyncOptions{Delete: true, DryRun: true})
+		errors.AssertNil(t, err)
+		assertSyncActions(t, report, []SyncAction{
+			{SyncDelete, "bar/extra", true},
+			{SyncDelete, "extra.txt", false},
+		})
+		assertIsFile(t, fs2, "/mirror/extra.txt")
+
+		_, err = Sync(fs1, "/foo", fs2, "/mirror", &SyncOptions{Delete: true})
+		errors.AssertNil(t, err)
+		assertNotExists(t, fs2, "/mirror/extra.txt")
+		assertNotExists(t, fs2, "/mirror/bar/extra")
+	})
+
+	t.Run("TestSyncTypeChanged", func(t *testing.T) {
+		errors.AssertNil(t, fs1.DeleteDirectory("/foo/test", true))
+		errors.AssertNil(t, fs1.WriteString("/foo/test", "now a file"))
+
+		report, err := Sync(fs1, "/foo", fs2, "/mirror", nil)
+		errors.AssertNil(t, err)
+		assertSyncActions(t, report, []SyncAction{
+			{SyncDelete, "test", true},
+			{SyncCopy, "test", false},
+		})
+		assertFileContent(t, fs2, "/mirror/test", "now a file")
+	})
+
+	t.Run("TestSyncNoDir", func(t *testing.T) {
+		_, err := Sync(fs1, "/foo/test.txt", fs2, "/mirror", nil)
+		errors.Assert(t, fs.ErrDirectoryNotExists, err)
+	})
+}
+
+func assertSyncActions(t *testing.T, report *SyncReport, expected []SyncAction) bool {
+	if !assert.NotNil(t, report) {
+		return false
+	}
+	return assert.Equal(t, expected, report.Actions)
+}
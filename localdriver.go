@@ -1,6 +1,7 @@
 package fs
 
 import (
+	"io"
 	"io/ioutil"
 	"os"
 
@@ -114,6 +115,50 @@ func (d *LocalDriver) ReadDir(path string) ([]FileInfo, errors.Error) {
 	return result, nil
 }
 
+// ListDir returns a cursor to list all files and directories contained in a directory in batches.
+func (d *LocalDriver) ListDir(path string) (DirCursor, errors.Error) {
+	rootedPath, err := d.root(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, openErr := os.Open(rootedPath)
+	if openErr != nil {
+		if os.IsNotExist(openErr) {
+			return nil, ErrDirectoryNotExists.Msg("Directory %q not found", path).Make()
+		}
+		return nil, Err.Msg("Failed to list directory content").Make().Cause(openErr)
+	}
+	return &localDirCursor{f}, nil
+}
+
+type localDirCursor struct {
+	f *os.File
+}
+
+func (c *localDirCursor) Next(n int) ([]FileInfo, errors.Error) {
+	if n <= 0 {
+		n = DefaultListBatchSize
+	}
+
+	items, readErr := c.f.Readdir(n)
+	result := make([]FileInfo, len(items))
+	for i := range items {
+		result[i] = items[i]
+	}
+	if readErr != nil && readErr != io.EOF {
+		return result, Err.Msg("Failed to list directory content").Make().Cause(readErr)
+	}
+	return result, nil
+}
+
+func (c *localDirCursor) Close() errors.Error {
+	if err := c.f.Close(); err != nil {
+		return Err.Msg("Failed to close directory").Make().Cause(err)
+	}
+	return nil
+}
+
 // OpenFile opens a file instance and returns the handle.
 func (d *LocalDriver) OpenFile(path string, flags OpenFlags) (File, errors.Error) {
 	rootedPath, err := d.root(path)
@@ -126,6 +171,9 @@ func (d *LocalDriver) OpenFile(path string, flags OpenFlags) (File, errors.Error
 		if os.IsNotExist(openErr) {
 			return nil, ErrFileNotExists.Args(path).Make()
 		}
+		if os.IsExist(openErr) {
+			return nil, ErrAlreadyExists.Args(path).Make()
+		}
 		return nil, Err.Msg("Could not open file").Make().Cause(openErr)
 	}
 	return f, nil
@@ -227,6 +275,152 @@ func (d *LocalDriver) MoveDir(src, dst string) errors.Error {
 	return nil
 }
 
+// CopyFile copies a file and overwrites the existing one. The content is cloned without copying any data on file systems that support reflinks.
+func (d *LocalDriver) CopyFile(src, dst string) errors.Error {
+	rootedSrc, err := d.root(src)
+	if err != nil {
+		return err
+	}
+	rootedDst, err := d.root(dst)
+	if err != nil {
+		return err
+	}
+
+	return copyLocalFile(rootedSrc, src, rootedDst, dst)
+}
+
+func copyLocalFile(rootedSrc, src, rootedDst, dst string) errors.Error {
+	fSrc, openErr := os.Open(rootedSrc)
+	if openErr != nil {
+		if os.IsNotExist(openErr) {
+			return ErrFileNotExists.Args(src).Make()
+		}
+		return Err.Msg("Could not open file").Make().Cause(openErr)
+	}
+	defer fSrc.Close()
+
+	fDst, createErr := os.OpenFile(rootedDst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.ModePerm)
+	if createErr != nil {
+		return Err.Msg("Could not create file").Make().Cause(createErr)
+	}
+
+	if !cloneFile(fDst, fSrc) {
+		// io.Copy uses copy_file_range for regular files where available
+		if _, err := io.Copy(fDst, fSrc); err != nil {
+			fDst.Close()
+			return Err.Msg("Failed to copy file").Make().Cause(err)
+		}
+	}
+
+	if err := fDst.Close(); err != nil {
+		return Err.Msg("Failed to close file %q", dst).Make().Cause(err)
+	}
+	return nil
+}
+
+// CopyDir recursively copies a directory overwriting all existing files.
+func (d *LocalDriver) CopyDir(src, dst string) errors.Error {
+	rootedSrc, err := d.root(src)
+	if err != nil {
+		return err
+	}
+	rootedDst, err := d.root(dst)
+	if err != nil {
+		return err
+	}
+
+	return copyLocalDir(rootedSrc, src, rootedDst, dst)
+}
+
+func copyLocalDir(rootedSrc, src, rootedDst, dst string) errors.Error {
+	files, readErr := ioutil.ReadDir(rootedSrc)
+	if readErr != nil {
+		if os.IsNotExist(readErr) {
+			return ErrDirectoryNotExists.Msg("Directory %q not found", src).Make()
+		}
+		return Err.Msg("Failed to list directory content").Make().Cause(readErr)
+	}
+
+	if err := os.MkdirAll(rootedDst, os.ModePerm); err != nil {
+		return Err.Msg("Failed to create directory").Make().Cause(err)
+	}
+
+	for _, f := range files {
+		var err errors.Error
+		if f.IsDir() {
+			err = copyLocalDir(path.Join(rootedSrc, f.Name()), path.Join(src, f.Name()), path.Join(rootedDst, f.Name()), path.Join(dst, f.Name()))
+		} else {
+			err = copyLocalFile(path.Join(rootedSrc, f.Name()), path.Join(src, f.Name()), path.Join(rootedDst, f.Name()), path.Join(dst, f.Name()))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadMetadata returns mode, modification time, owner and extended attributes of a file or directory. Owner and extended attributes are only available on Linux.
+func (d *LocalDriver) ReadMetadata(path string) (*Metadata, errors.Error) {
+	rootedPath, err := d.root(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, statErr := os.Stat(rootedPath)
+	if statErr != nil {
+		if os.IsNotExist(statErr) {
+			return nil, ErrNotExists.Args(path).Make()
+		}
+		return nil, Err.Msg("Failed to access path %q", path).Make().Cause(statErr)
+	}
+
+	mode := fi.Mode() & metadataModeMask
+	modTime := fi.ModTime()
+	xattrs, xattrErr := readXattrs(rootedPath)
+	if xattrErr != nil {
+		return nil, Err.Msg("Could not read extended attributes").Make().Cause(xattrErr)
+	}
+	return &Metadata{Mode: &mode, ModTime: &modTime, Owner: fileOwner(fi), Xattrs: xattrs}, nil
+}
+
+// WriteMetadata applies all known attributes to a file or directory. Owner and extended attributes are ignored when they cannot be stored due to missing privileges or file system support.
+func (d *LocalDriver) WriteMetadata(path string, metadata *Metadata) errors.Error {
+	rootedPath, err := d.root(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(rootedPath); err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotExists.Args(path).Make()
+		}
+		return Err.Msg("Failed to access path %q", path).Make().Cause(err)
+	}
+
+	if metadata.Xattrs != nil {
+		if err := writeXattrs(rootedPath, metadata.Xattrs); err != nil {
+			return Err.Msg("Could not write extended attributes").Make().Cause(err)
+		}
+	}
+	// changing the owner resets setuid and setgid bits and must be done before applying the mode
+	if metadata.Owner != nil {
+		if err := setOwner(rootedPath, metadata.Owner); err != nil {
+			return Err.Msg("Could not change owner").Make().Cause(err)
+		}
+	}
+	if metadata.Mode != nil {
+		if err := os.Chmod(rootedPath, *metadata.Mode); err != nil {
+			return Err.Msg("Could not change mode").Make().Cause(err)
+		}
+	}
+	if metadata.ModTime != nil {
+		if err := os.Chtimes(rootedPath, *metadata.ModTime, *metadata.ModTime); err != nil {
+			return Err.Msg("Could not change modification time").Make().Cause(err)
+		}
+	}
+	return nil
+}
+
 // GetTempFile returns the path to an empty temporary file.
 func (d *LocalDriver) GetTempFile(pattern string) (string, errors.Error) {
 	if len(d.Root) > 0 {
@@ -237,7 +431,9 @@ func (d *LocalDriver) GetTempFile(pattern string) (string, errors.Error) {
 	if err != nil {
 		return "", Err.Msg("Failed to create temporary file").Make().Cause(err)
 	}
-	defer tmpFile.Close()
+	if err := tmpFile.Close(); err != nil {
+		return "", Err.Msg("Failed to close temporary file").Make().Cause(err)
+	}
 	return tmpFile.Name(), nil
 }
 
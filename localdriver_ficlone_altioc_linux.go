@@ -0,0 +1,10 @@
+//go:build linux && (mips || mipsle || mips64 || mips64le || ppc64 || ppc64le || sparc64)
+// +build linux
+// +build mips mipsle mips64 mips64le ppc64 ppc64le sparc64
+
+package fs
+
+const (
+	// FICLONE from linux/fs.h, defined as _IOW(0x94, 9, int) with the write direction encoded as 4 on these architectures
+	ficlone = 0x80049409
+)
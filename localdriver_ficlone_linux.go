@@ -0,0 +1,9 @@
+//go:build linux && !mips && !mipsle && !mips64 && !mips64le && !ppc64 && !ppc64le && !sparc64
+// +build linux,!mips,!mipsle,!mips64,!mips64le,!ppc64,!ppc64le,!sparc64
+
+package fs
+
+const (
+	// FICLONE from linux/fs.h, defined as _IOW(0x94, 9, int)
+	ficlone = 0x40049409
+)
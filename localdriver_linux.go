@@ -0,0 +1,172 @@
+package fs
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+
+	"github.com/sbreitf1/errors"
+)
+
+const (
+	// ST_RDONLY from statvfs.h
+	stReadOnly = 0x1
+)
+
+var (
+	fileSystemTypeNames = map[uint32]string{
+		0x9123683e: "btrfs",
+		0xef53:     "ext4",
+		0x58465342: "xfs",
+		0x2fc12fc1: "zfs",
+		0x01021994: "tmpfs",
+		0x794c7630: "overlay",
+		0x6969:     "nfs",
+		0xff534d42: "cifs",
+		0xfe534d42: "smb2",
+		0x4d44:     "vfat",
+		0x5346544e: "ntfs",
+		0x65735546: "fuse",
+		0x9fa0:     "proc",
+		0x62656572: "sysfs",
+		0x858458f6: "ramfs",
+		0x73717368: "squashfs",
+		0x9660:     "iso9660",
+	}
+)
+
+// VolumeInfo returns information about the volume the given path is located on.
+func (d *LocalDriver) VolumeInfo(path string) (VolumeStats, errors.Error) {
+	rootedPath, err := d.root(path)
+	if err != nil {
+		return VolumeStats{}, err
+	}
+
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(rootedPath, &stat); err != nil {
+		if err == syscall.ENOENT {
+			return VolumeStats{}, ErrNotExists.Args(path).Make()
+		}
+		return VolumeStats{}, Err.Msg("Failed to retrieve volume information").Make().Cause(err)
+	}
+
+	blockSize := uint64(stat.Bsize)
+	return VolumeStats{
+		TotalBytes:     stat.Blocks * blockSize,
+		FreeBytes:      stat.Bfree * blockSize,
+		AvailableBytes: stat.Bavail * blockSize,
+		ReadOnly:       (stat.Flags & stReadOnly) != 0,
+		FileSystemType: fileSystemTypeNames[uint32(stat.Type)],
+	}, nil
+}
+
+// cloneFile shares the data blocks of src with dst on file systems supporting reflinks like btrfs and xfs. Returns false if the file could not be cloned.
+func cloneFile(dst, src *os.File) bool {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dst.Fd(), ficlone, src.Fd())
+	return errno == 0
+}
+
+func fileOwner(fi os.FileInfo) *Owner {
+	if stat, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return &Owner{UID: int(stat.Uid), GID: int(stat.Gid)}
+	}
+	return nil
+}
+
+func setOwner(path string, owner *Owner) error {
+	if err := os.Chown(path, owner.UID, owner.GID); err != nil && !os.IsPermission(err) {
+		return err
+	}
+	return nil
+}
+
+func readXattrs(path string) (map[string][]byte, error) {
+	names, err := listXattrs(path)
+	if err != nil {
+		if isXattrNotSupported(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	xattrs := make(map[string][]byte, len(names))
+	for _, name := range names {
+		value, err := getXattr(path, name)
+		if err != nil {
+			if err == syscall.ENODATA || isXattrNotSupported(err) {
+				// attribute has been removed concurrently or is not accessible
+				continue
+			}
+			return nil, err
+		}
+		xattrs[name] = value
+	}
+	return xattrs, nil
+}
+
+func listXattrs(path string) ([]string, error) {
+	for {
+		size, err := syscall.Listxattr(path, nil)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return []string{}, nil
+		}
+
+		buf := make([]byte, size)
+		n, err := syscall.Listxattr(path, buf)
+		if err == syscall.ERANGE {
+			// list has grown in the meantime
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		names := make([]string, 0)
+		for _, name := range bytes.Split(buf[:n], []byte{0}) {
+			if len(name) > 0 {
+				names = append(names, string(name))
+			}
+		}
+		return names, nil
+	}
+}
+
+func getXattr(path, name string) ([]byte, error) {
+	for {
+		size, err := syscall.Getxattr(path, name, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, size)
+		n, err := syscall.Getxattr(path, name, buf)
+		if err == syscall.ERANGE {
+			// value has grown in the meantime
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
+
+func writeXattrs(path string, xattrs map[string][]byte) error {
+	for name, value := range xattrs {
+		if err := syscall.Setxattr(path, name, value, 0); err != nil {
+			// restricted namespaces like security and trusted require privileges
+			if isXattrNotSupported(err) || err == syscall.EPERM || err == syscall.EACCES {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func isXattrNotSupported(err error) bool {
+	return err == syscall.ENOTSUP || err == syscall.EOPNOTSUPP
+}
@@ -0,0 +1,70 @@
+package fs
+
+import (
+	"math"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/sbreitf1/fs/path"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalDriverVolumeInfo(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		fs := NewWithDriver(&LocalDriver{Root: tmpDir})
+		assert.True(t, fs.CanVolumeInfo())
+
+		info, err := fs.VolumeInfo("/")
+		if errors.AssertNil(t, err) {
+			assert.True(t, info.TotalBytes > 0)
+			assert.True(t, info.FreeBytes <= info.TotalBytes)
+			assert.True(t, info.AvailableBytes <= info.FreeBytes)
+		}
+
+		_, err = fs.VolumeInfo("/nonexistingdir")
+		errors.Assert(t, ErrNotExists, err)
+
+		errors.AssertNil(t, fs.EnsureFreeSpace("/nonexistingdir/subdir", 1))
+		errors.Assert(t, ErrInsufficientSpace, fs.EnsureFreeSpace("/", math.MaxUint64))
+
+		errors.AssertNil(t, fs.WriteString("/test.txt", "foo bar"))
+		errors.AssertNil(t, fs.CreateDirectory("/dir"))
+		errors.AssertNil(t, fs.CopyFileWithOptions("/test.txt", "/dir/test.txt", &CopyOptions{CheckFreeSpace: true}))
+		errors.AssertNil(t, fs.CopyDirWithOptions("/dir", "/dir2", &CopyOptions{CheckFreeSpace: true}))
+		assertFileContent(t, fs, "/dir2/test.txt", "foo bar")
+		return nil
+	}))
+}
+
+func TestLocalDriverMetadata(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		fs := NewWithDriver(&LocalDriver{Root: tmpDir})
+		errors.AssertNil(t, fs.WriteString("/test.txt", "foo bar"))
+		if err := syscall.Setxattr(path.Join(tmpDir, "/test.txt"), "user.fs-test", []byte("blub"), 0); err != nil {
+			t.Skipf("Extended attributes are not supported: %s", err.Error())
+		}
+
+		metadata, err := fs.ReadMetadata("/test.txt")
+		errors.AssertNil(t, err)
+		assert.Equal(t, &Owner{UID: os.Getuid(), GID: os.Getgid()}, metadata.Owner)
+		assert.Equal(t, []byte("blub"), metadata.Xattrs["user.fs-test"])
+
+		errors.AssertNil(t, fs.CopyFileWithOptions("/test.txt", "/copy.txt", &CopyOptions{PreserveOwner: true, PreserveXattrs: true}))
+		copied, err := fs.ReadMetadata("/copy.txt")
+		errors.AssertNil(t, err)
+		assert.Equal(t, metadata.Owner, copied.Owner)
+		assert.Equal(t, []byte("blub"), copied.Xattrs["user.fs-test"])
+
+		errors.AssertNil(t, fs.CopyFile("/test.txt", "/plain.txt"))
+		plain, err := fs.ReadMetadata("/plain.txt")
+		errors.AssertNil(t, err)
+		assert.NotContains(t, plain.Xattrs, "user.fs-test")
+
+		_, err = fs.ReadMetadata("/nonexisting.txt")
+		errors.Assert(t, ErrNotExists, err)
+		return nil
+	}))
+}
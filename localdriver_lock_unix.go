@@ -0,0 +1,54 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package fs
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/sbreitf1/errors"
+)
+
+// LockFile acquires an advisory lock on an opened file using flock. The lock is bound to the file handle and released when it is closed.
+func (d *LocalDriver) LockFile(f File, path string, mode LockMode, blocking bool) errors.Error {
+	osFile, ok := f.(*os.File)
+	if !ok {
+		return ErrNotSupported.Msg("Only files opened by LocalDriver can be locked").Make()
+	}
+
+	how := syscall.LOCK_SH
+	if mode == LockExclusive {
+		how = syscall.LOCK_EX
+	}
+	if !blocking {
+		how |= syscall.LOCK_NB
+	}
+
+	for {
+		err := syscall.Flock(int(osFile.Fd()), how)
+		if err == nil {
+			return nil
+		}
+		if err == syscall.EINTR {
+			continue
+		}
+		if err == syscall.EWOULDBLOCK {
+			return ErrLocked.Args(path).Make()
+		}
+		return Err.Msg("Failed to lock file %q", path).Make().Cause(err)
+	}
+}
+
+// UnlockFile releases an advisory lock acquired by LockFile.
+func (d *LocalDriver) UnlockFile(f File, path string) errors.Error {
+	osFile, ok := f.(*os.File)
+	if !ok {
+		return ErrNotSupported.Msg("Only files opened by LocalDriver can be unlocked").Make()
+	}
+
+	if err := syscall.Flock(int(osFile.Fd()), syscall.LOCK_UN); err != nil {
+		return Err.Msg("Failed to unlock file %q", path).Make().Cause(err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+//go:build windows
+// +build windows
+
+package fs
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+
+	"github.com/sbreitf1/errors"
+)
+
+const (
+	lockfileFailImmediately = 0x00000001
+	lockfileExclusiveLock   = 0x00000002
+
+	errorLockViolation syscall.Errno = 33
+	errorIOPending     syscall.Errno = 997
+
+	// lockRangeOffsetHigh denotes the upper 32 bits of the locked byte. The byte is located far beyond the end of all realistic files, so that the mandatory lock does not affect reading and writing the content.
+	lockRangeOffsetHigh = 0x40000000
+)
+
+var (
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+// LockFile acquires a lock on an opened file using LockFileEx. The lock is bound to the file handle and released when it is closed.
+//
+// Locks of LockFileEx are mandatory, so only a single byte beyond the end of the file is locked instead of the whole file. Thus, holders of the lock can still read and write the file through other handles like with flock on Unix systems.
+func (d *LocalDriver) LockFile(f File, path string, mode LockMode, blocking bool) errors.Error {
+	osFile, ok := f.(*os.File)
+	if !ok {
+		return ErrNotSupported.Msg("Only files opened by LocalDriver can be locked").Make()
+	}
+
+	var flags uint32
+	if mode == LockExclusive {
+		flags |= lockfileExclusiveLock
+	}
+	if !blocking {
+		flags |= lockfileFailImmediately
+	}
+
+	overlapped := syscall.Overlapped{OffsetHigh: lockRangeOffsetHigh}
+	r, _, err := procLockFileEx.Call(osFile.Fd(), uintptr(flags), 0, 1, 0, uintptr(unsafe.Pointer(&overlapped)))
+	if r != 0 {
+		return nil
+	}
+	if err == errorLockViolation || err == errorIOPending {
+		return ErrLocked.Args(path).Make()
+	}
+	return Err.Msg("Failed to lock file %q", path).Make().Cause(err)
+}
+
+// UnlockFile releases a lock acquired by LockFile.
+func (d *LocalDriver) UnlockFile(f File, path string) errors.Error {
+	osFile, ok := f.(*os.File)
+	if !ok {
+		return ErrNotSupported.Msg("Only files opened by LocalDriver can be unlocked").Make()
+	}
+
+	overlapped := syscall.Overlapped{OffsetHigh: lockRangeOffsetHigh}
+	r, _, err := procUnlockFileEx.Call(osFile.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(&overlapped)))
+	if r == 0 {
+		return Err.Msg("Failed to unlock file %q", path).Make().Cause(err)
+	}
+	return nil
+}
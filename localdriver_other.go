@@ -0,0 +1,33 @@
+//go:build !linux
+// +build !linux
+
+package fs
+
+import (
+	"os"
+)
+
+// cloneFile is only supported on Linux.
+func cloneFile(dst, src *os.File) bool {
+	return false
+}
+
+// fileOwner is only supported on Linux.
+func fileOwner(fi os.FileInfo) *Owner {
+	return nil
+}
+
+// setOwner is only supported on Linux.
+func setOwner(path string, owner *Owner) error {
+	return nil
+}
+
+// readXattrs is only supported on Linux.
+func readXattrs(path string) (map[string][]byte, error) {
+	return nil, nil
+}
+
+// writeXattrs is only supported on Linux.
+func writeXattrs(path string, xattrs map[string][]byte) error {
+	return nil
+}
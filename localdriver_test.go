@@ -71,6 +71,34 @@ func testLocalDriver(t *testing.T, driver *LocalDriver, rootDir, workingDir stri
 		assert.False(t, files[0].IsDir())
 	})
 
+	t.Run("TestListDir", func(t *testing.T) {
+		if err := ioutil.WriteFile(path.Join(rootDir, workingDir, "/test2.txt"), []byte("test data"), os.ModePerm); err != nil {
+			panic(err)
+		}
+		defer os.Remove(path.Join(rootDir, workingDir, "/test2.txt"))
+
+		cursor, err := driver.ListDir(path.Join(workingDir, "/"))
+		errors.AssertNil(t, err)
+		defer cursor.Close()
+
+		names := make([]string, 0)
+		for {
+			files, err := cursor.Next(1)
+			errors.AssertNil(t, err)
+			if len(files) == 0 {
+				break
+			}
+			assert.Equal(t, 1, len(files))
+			names = append(names, files[0].Name())
+		}
+		assert.ElementsMatch(t, []string{"test.txt", "test2.txt"}, names)
+	})
+
+	t.Run("TestListDirNonExistent", func(t *testing.T) {
+		_, err := driver.ListDir(path.Join(workingDir, "/nonexistingpath"))
+		errors.Assert(t, ErrDirectoryNotExists, err)
+	})
+
 	t.Run("TestStatNonExistent", func(t *testing.T) {
 		_, err := driver.Stat(path.Join(workingDir, "/newdir/and"))
 		errors.Assert(t, ErrNotExists, err)
@@ -133,6 +161,26 @@ func testLocalDriver(t *testing.T, driver *LocalDriver, rootDir, workingDir stri
 		assert.DirExists(t, path.Join(rootDir, workingDir, "/foo/subdir"))
 	})
 
+	t.Run("TestCopyFile", func(t *testing.T) {
+		errors.AssertNil(t, driver.CopyFile(path.Join(workingDir, "/foo/testfile.txt"), path.Join(workingDir, "/foo/copy.txt")))
+		data, readErr := ioutil.ReadFile(path.Join(rootDir, workingDir, "/foo/copy.txt"))
+		errors.AssertNil(t, readErr)
+		assert.Equal(t, "some test data", string(data))
+
+		errors.Assert(t, ErrFileNotExists, driver.CopyFile(path.Join(workingDir, "/foo/nonexisting.txt"), path.Join(workingDir, "/foo/copy2.txt")))
+	})
+
+	t.Run("TestCopyDir", func(t *testing.T) {
+		errors.AssertNil(t, driver.CopyDir(path.Join(workingDir, "/foo"), path.Join(workingDir, "/foocopy")))
+		assert.DirExists(t, path.Join(rootDir, workingDir, "/foocopy/subdir"))
+		data, readErr := ioutil.ReadFile(path.Join(rootDir, workingDir, "/foocopy/testfile.txt"))
+		errors.AssertNil(t, readErr)
+		assert.Equal(t, "some test data", string(data))
+
+		errors.Assert(t, ErrDirectoryNotExists, driver.CopyDir(path.Join(workingDir, "/nonexistingdir"), path.Join(workingDir, "/foocopy2")))
+		os.RemoveAll(path.Join(rootDir, workingDir, "/foocopy"))
+	})
+
 	t.Run("TestDeleteFile", func(t *testing.T) {
 		errors.AssertNil(t, driver.DeleteFile(path.Join(workingDir, "/foo/testfile.txt")))
 		_, err := os.Stat(path.Join(rootDir, workingDir, "/foo/testfile.txt"))
@@ -0,0 +1,55 @@
+//go:build darwin || freebsd || dragonfly
+// +build darwin freebsd dragonfly
+
+package fs
+
+import (
+	"syscall"
+
+	"github.com/sbreitf1/errors"
+)
+
+const (
+	// MNT_RDONLY from sys/mount.h
+	mntReadOnly = 0x1
+)
+
+// VolumeInfo returns information about the volume the given path is located on.
+func (d *LocalDriver) VolumeInfo(path string) (VolumeStats, errors.Error) {
+	rootedPath, err := d.root(path)
+	if err != nil {
+		return VolumeStats{}, err
+	}
+
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(rootedPath, &stat); err != nil {
+		if err == syscall.ENOENT {
+			return VolumeStats{}, ErrNotExists.Args(path).Make()
+		}
+		return VolumeStats{}, Err.Msg("Failed to retrieve volume information").Make().Cause(err)
+	}
+
+	blockSize := uint64(stat.Bsize)
+	info := VolumeStats{
+		TotalBytes:     uint64(stat.Blocks) * blockSize,
+		FreeBytes:      uint64(stat.Bfree) * blockSize,
+		ReadOnly:       (uint64(stat.Flags) & mntReadOnly) != 0,
+		FileSystemType: fileSystemTypeName(stat.Fstypename),
+	}
+	// available blocks are negative on FreeBSD when the reserved space is in use
+	if stat.Bavail > 0 {
+		info.AvailableBytes = uint64(stat.Bavail) * blockSize
+	}
+	return info, nil
+}
+
+func fileSystemTypeName(name [16]int8) string {
+	b := make([]byte, 0, len(name))
+	for _, c := range name {
+		if c == 0 {
+			break
+		}
+		b = append(b, byte(c))
+	}
+	return string(b)
+}
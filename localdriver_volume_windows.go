@@ -0,0 +1,58 @@
+//go:build windows
+// +build windows
+
+package fs
+
+import (
+	"syscall"
+	"unsafe"
+
+	"github.com/sbreitf1/errors"
+)
+
+const (
+	// FILE_READ_ONLY_VOLUME from winnt.h
+	fileReadOnlyVolume = 0x00080000
+)
+
+var (
+	procGetDiskFreeSpaceExW   = kernel32.NewProc("GetDiskFreeSpaceExW")
+	procGetVolumePathNameW    = kernel32.NewProc("GetVolumePathNameW")
+	procGetVolumeInformationW = kernel32.NewProc("GetVolumeInformationW")
+)
+
+// VolumeInfo returns information about the volume the given path is located on.
+func (d *LocalDriver) VolumeInfo(path string) (VolumeStats, errors.Error) {
+	rootedPath, err := d.root(path)
+	if err != nil {
+		return VolumeStats{}, err
+	}
+
+	pathPtr, convErr := syscall.UTF16PtrFromString(rootedPath)
+	if convErr != nil {
+		return VolumeStats{}, Err.Msg("Failed to retrieve volume information").Make().Cause(convErr)
+	}
+
+	var info VolumeStats
+	r, _, callErr := procGetDiskFreeSpaceExW.Call(uintptr(unsafe.Pointer(pathPtr)), uintptr(unsafe.Pointer(&info.AvailableBytes)), uintptr(unsafe.Pointer(&info.TotalBytes)), uintptr(unsafe.Pointer(&info.FreeBytes)))
+	if r == 0 {
+		if callErr == syscall.ERROR_FILE_NOT_FOUND || callErr == syscall.ERROR_PATH_NOT_FOUND {
+			return VolumeStats{}, ErrNotExists.Args(path).Make()
+		}
+		return VolumeStats{}, Err.Msg("Failed to retrieve volume information").Make().Cause(callErr)
+	}
+
+	// read-only flag and file system type are optional, because they require the volume root
+	volumePath := make([]uint16, syscall.MAX_PATH+1)
+	r, _, _ = procGetVolumePathNameW.Call(uintptr(unsafe.Pointer(pathPtr)), uintptr(unsafe.Pointer(&volumePath[0])), uintptr(len(volumePath)))
+	if r != 0 {
+		var flags uint32
+		fsName := make([]uint16, syscall.MAX_PATH+1)
+		r, _, _ = procGetVolumeInformationW.Call(uintptr(unsafe.Pointer(&volumePath[0])), 0, 0, 0, 0, uintptr(unsafe.Pointer(&flags)), uintptr(unsafe.Pointer(&fsName[0])), uintptr(len(fsName)))
+		if r != 0 {
+			info.ReadOnly = (flags & fileReadOnlyVolume) != 0
+			info.FileSystemType = syscall.UTF16ToString(fsName)
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,297 @@
+package fs
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/sbreitf1/fs/path"
+
+	"github.com/sbreitf1/errors"
+)
+
+const (
+	inotifyWatchMask = syscall.IN_CREATE | syscall.IN_MODIFY | syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+)
+
+// Watch returns a watcher that uses inotify to report changes of a file or the content of a directory.
+func (d *LocalDriver) Watch(path string, recursive bool) (Watcher, errors.Error) {
+	rootedPath, err := d.root(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, statErr := os.Stat(rootedPath)
+	if statErr != nil {
+		if os.IsNotExist(statErr) {
+			return nil, ErrNotExists.Args(path).Make()
+		}
+		return nil, Err.Msg("Failed to access path %q", path).Make().Cause(statErr)
+	}
+
+	fd, initErr := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if initErr != nil {
+		return nil, Err.Msg("Failed to initialize inotify").Make().Cause(initErr)
+	}
+
+	w := &inotifyWatcher{
+		driver:    d,
+		file:      os.NewFile(uintptr(fd), "inotify"),
+		fd:        fd,
+		root:      path,
+		rootIsDir: fi.IsDir(),
+		recursive: recursive && fi.IsDir(),
+		watches:   make(map[int]string),
+		events:    make(chan WatchEvent, watchEventBufferSize),
+		errs:      make(chan errors.Error, 1),
+		done:      make(chan struct{}),
+	}
+
+	if w.recursive {
+		err = w.addRecursive(path, nil)
+	} else {
+		err = w.add(path)
+	}
+	if err != nil {
+		w.file.Close()
+		return nil, err
+	}
+
+	w.wg.Add(1)
+	go w.run()
+	return w, nil
+}
+
+type inotifyWatcher struct {
+	driver    *LocalDriver
+	file      *os.File
+	fd        int
+	root      string
+	rootIsDir bool
+	recursive bool
+	// watches maps watch descriptors to the watched path
+	watches   map[int]string
+	events    chan WatchEvent
+	errs      chan errors.Error
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+func (w *inotifyWatcher) Events() <-chan WatchEvent {
+	return w.events
+}
+
+func (w *inotifyWatcher) Errors() <-chan errors.Error {
+	return w.errs
+}
+
+func (w *inotifyWatcher) Close() errors.Error {
+	var err errors.Error
+	w.closeOnce.Do(func() {
+		close(w.done)
+		// closing the file interrupts the pending read
+		if closeErr := w.file.Close(); closeErr != nil {
+			err = Err.Msg("Failed to close inotify instance").Make().Cause(closeErr)
+		}
+		w.wg.Wait()
+		close(w.events)
+		close(w.errs)
+	})
+	return err
+}
+
+func (w *inotifyWatcher) add(p string) errors.Error {
+	rootedPath, err := w.driver.root(p)
+	if err != nil {
+		return err
+	}
+
+	wd, watchErr := syscall.InotifyAddWatch(w.fd, rootedPath, inotifyWatchMask)
+	if watchErr != nil {
+		if watchErr == syscall.ENOENT {
+			return ErrNotExists.Args(p).Make()
+		}
+		return Err.Msg("Failed to watch path %q", p).Make().Cause(watchErr)
+	}
+	w.watches[wd] = p
+	return nil
+}
+
+// addRecursive watches a directory and all sub-directories. If created is not nil, all contained elements are appended as create events, because they might have been created before the watch was added.
+func (w *inotifyWatcher) addRecursive(dir string, created *[]WatchEvent) errors.Error {
+	if err := w.add(dir); err != nil {
+		return err
+	}
+
+	rootedPath, err := w.driver.root(dir)
+	if err != nil {
+		return err
+	}
+	files, readErr := ioutil.ReadDir(rootedPath)
+	if readErr != nil {
+		if os.IsNotExist(readErr) {
+			// already removed again
+			return nil
+		}
+		return Err.Msg("Failed to list directory content").Make().Cause(readErr)
+	}
+
+	for _, f := range files {
+		p := path.Join(dir, f.Name())
+		if created != nil {
+			*created = append(*created, WatchEvent{Type: EventCreate, Path: p, IsDir: f.IsDir()})
+		}
+		if f.IsDir() {
+			if err := w.addRecursive(p, created); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// removeRecursive forgets all watches of a directory and its sub-directories.
+func (w *inotifyWatcher) removeRecursive(dir string) {
+	for wd, p := range w.watches {
+		if p == dir || strings.HasPrefix(p, dir+"/") {
+			syscall.InotifyRmWatch(w.fd, uint32(wd))
+			delete(w.watches, wd)
+		}
+	}
+}
+
+// renameRecursive updates all watched paths of a moved directory.
+func (w *inotifyWatcher) renameRecursive(oldDir, newDir string) {
+	for wd, p := range w.watches {
+		if p == oldDir {
+			w.watches[wd] = newDir
+		} else if strings.HasPrefix(p, oldDir+"/") {
+			w.watches[wd] = newDir + p[len(oldDir):]
+		}
+	}
+}
+
+func (w *inotifyWatcher) run() {
+	defer w.wg.Done()
+
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, readErr := w.file.Read(buf)
+		if readErr != nil {
+			select {
+			case <-w.done:
+			default:
+				w.sendError(Err.Msg("Failed to read inotify events").Make().Cause(readErr))
+			}
+			return
+		}
+
+		for _, event := range w.parse(buf[:n]) {
+			if !w.sendEvent(event) {
+				return
+			}
+		}
+	}
+}
+
+// parse converts raw inotify events to watch events. Moves inside the watched tree are reported as rename, moves from or to outside the tree as delete or create.
+func (w *inotifyWatcher) parse(buf []byte) []WatchEvent {
+	events := make([]WatchEvent, 0)
+	var movedFrom *WatchEvent
+	var movedCookie uint32
+
+	flushMove := func() {
+		if movedFrom != nil {
+			movedFrom.Type = EventDelete
+			events = append(events, *movedFrom)
+			if movedFrom.IsDir {
+				w.removeRecursive(movedFrom.Path)
+			}
+			movedFrom = nil
+		}
+	}
+
+	for offset := 0; offset+syscall.SizeofInotifyEvent <= len(buf); {
+		raw := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+		nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(raw.Len)]
+		offset += syscall.SizeofInotifyEvent + int(raw.Len)
+
+		if raw.Mask&syscall.IN_Q_OVERFLOW != 0 {
+			flushMove()
+			w.sendError(ErrWatchOverflow.Make())
+			continue
+		}
+
+		watched, ok := w.watches[int(raw.Wd)]
+		if !ok {
+			continue
+		}
+		if raw.Mask&syscall.IN_IGNORED != 0 {
+			delete(w.watches, int(raw.Wd))
+			continue
+		}
+
+		p := watched
+		name := string(bytes.TrimRight(nameBytes, "\x00"))
+		if len(name) > 0 {
+			p = path.Join(watched, name)
+		}
+		isDir := raw.Mask&syscall.IN_ISDIR != 0
+
+		if raw.Mask&syscall.IN_MOVED_TO != 0 && movedFrom != nil && raw.Cookie == movedCookie {
+			events = append(events, WatchEvent{Type: EventRename, Path: p, OldPath: movedFrom.Path, IsDir: isDir})
+			if isDir && w.recursive {
+				w.renameRecursive(movedFrom.Path, p)
+			}
+			movedFrom = nil
+			continue
+		}
+		flushMove()
+
+		switch {
+		case raw.Mask&syscall.IN_MOVED_FROM != 0:
+			movedFrom = &WatchEvent{Path: p, IsDir: isDir}
+			movedCookie = raw.Cookie
+
+		case raw.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+			events = append(events, WatchEvent{Type: EventCreate, Path: p, IsDir: isDir})
+			if isDir && w.recursive {
+				if err := w.addRecursive(p, &events); err != nil && !errors.InstanceOf(err, ErrNotExists) {
+					w.sendError(err)
+				}
+			}
+
+		case raw.Mask&syscall.IN_MODIFY != 0:
+			events = append(events, WatchEvent{Type: EventModify, Path: p, IsDir: isDir})
+
+		case raw.Mask&syscall.IN_DELETE != 0:
+			events = append(events, WatchEvent{Type: EventDelete, Path: p, IsDir: isDir})
+
+		case raw.Mask&(syscall.IN_DELETE_SELF|syscall.IN_MOVE_SELF) != 0:
+			// only report the watched path itself, sub-directories are reported by their parents
+			if watched == w.root {
+				events = append(events, WatchEvent{Type: EventDelete, Path: p, IsDir: w.rootIsDir})
+			}
+		}
+	}
+	flushMove()
+	return events
+}
+
+func (w *inotifyWatcher) sendEvent(event WatchEvent) bool {
+	select {
+	case w.events <- event:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+func (w *inotifyWatcher) sendError(err errors.Error) bool {
+	return sendWatchError(w.errs, w.done, err)
+}
@@ -0,0 +1,70 @@
+package fs
+
+import (
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalDriverWatch(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		fs := NewWithDriver(&LocalDriver{Root: tmpDir})
+		errors.AssertNil(t, fs.CreateDirectory("/watched"))
+
+		_, err := fs.Watch("/nonexisting", true)
+		errors.Assert(t, ErrNotExists, err)
+
+		t.Run("TestRecursive", func(t *testing.T) {
+			w, err := fs.Watch("/watched", true)
+			errors.AssertNil(t, err)
+			defer w.Close()
+			_, isNative := w.(*inotifyWatcher)
+			assert.True(t, isNative)
+
+			errors.AssertNil(t, fs.WriteString("/watched/test.txt", "foo"))
+			waitForEvent(t, w, WatchEvent{Type: EventCreate, Path: "/watched/test.txt"})
+			waitForEvent(t, w, WatchEvent{Type: EventModify, Path: "/watched/test.txt"})
+
+			errors.AssertNil(t, fs.CreateDirectory("/watched/sub"))
+			waitForEvent(t, w, WatchEvent{Type: EventCreate, Path: "/watched/sub", IsDir: true})
+			errors.AssertNil(t, fs.WriteString("/watched/sub/blub.txt", "bar"))
+			waitForEvent(t, w, WatchEvent{Type: EventCreate, Path: "/watched/sub/blub.txt"})
+
+			errors.AssertNil(t, fs.MoveFile("/watched/test.txt", "/watched/sub/moved.txt"))
+			waitForEvent(t, w, WatchEvent{Type: EventRename, Path: "/watched/sub/moved.txt", OldPath: "/watched/test.txt"})
+
+			// watches of renamed directories are updated
+			errors.AssertNil(t, fs.MoveDir("/watched/sub", "/watched/renamed"))
+			waitForEvent(t, w, WatchEvent{Type: EventRename, Path: "/watched/renamed", OldPath: "/watched/sub", IsDir: true})
+			errors.AssertNil(t, fs.DeleteFile("/watched/renamed/blub.txt"))
+			waitForEvent(t, w, WatchEvent{Type: EventDelete, Path: "/watched/renamed/blub.txt"})
+
+			// moving out of the watched tree is reported as delete
+			errors.AssertNil(t, fs.MoveDir("/watched/renamed", "/outside"))
+			waitForEvent(t, w, WatchEvent{Type: EventDelete, Path: "/watched/renamed", IsDir: true})
+		})
+
+		t.Run("TestFile", func(t *testing.T) {
+			errors.AssertNil(t, fs.WriteString("/file.txt", "foo"))
+			w, err := fs.Watch("/file.txt", false)
+			errors.AssertNil(t, err)
+			defer w.Close()
+
+			errors.AssertNil(t, fs.WriteString("/file.txt", "foo bar"))
+			waitForEvent(t, w, WatchEvent{Type: EventModify, Path: "/file.txt"})
+			errors.AssertNil(t, fs.DeleteFile("/file.txt"))
+			waitForEvent(t, w, WatchEvent{Type: EventDelete, Path: "/file.txt"})
+		})
+
+		t.Run("TestClose", func(t *testing.T) {
+			w, err := fs.Watch("/watched", false)
+			errors.AssertNil(t, err)
+			errors.AssertNil(t, w.Close())
+			errors.AssertNil(t, w.Close())
+			_, ok := <-w.Events()
+			assert.False(t, ok)
+		})
+		return nil
+	}))
+}
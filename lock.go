@@ -0,0 +1,198 @@
+package fs
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/sbreitf1/errors"
+)
+
+var (
+	// ErrLocked occurs when a non-blocking lock could not be acquired, because the file is locked by someone else.
+	ErrLocked = errors.New("The file %q is locked")
+)
+
+// LockMode denotes whether a lock can be shared with other readers.
+type LockMode int
+
+const (
+	// LockShared allows multiple holders at the same time, but no exclusive lock.
+	LockShared LockMode = iota
+	// LockExclusive allows only a single holder.
+	LockExclusive
+)
+
+// LockFileSystemDriver describes optional functionality for drivers that support advisory locks on opened files. Locks are released by UnlockFile or when the file is closed. Drivers should return ErrLocked when a non-blocking lock cannot be acquired. Drivers wrapping another driver must pass the file opened by the wrapped driver, because ErrNotSupported causes FileSystem to fall back to in-process locks.
+type LockFileSystemDriver interface {
+	LockFile(f File, path string, mode LockMode, blocking bool) errors.Error
+	UnlockFile(f File, path string) errors.Error
+}
+
+// FileLock is an advisory lock held on a file.
+type FileLock struct {
+	fs       *FileSystem
+	path     string
+	file     File
+	released bool
+}
+
+// Path returns the path of the locked file.
+func (l *FileLock) Path() string {
+	return l.path
+}
+
+// Unlock releases the lock. Calling Unlock multiple times has no effect.
+func (l *FileLock) Unlock() errors.Error {
+	if l.released {
+		return nil
+	}
+	l.released = true
+
+	if l.file == nil {
+		processLocks.unlock(processLockKey{l.fs.processLockOwner(), l.path})
+		return nil
+	}
+
+	driver := l.fs.navDriver.(LockFileSystemDriver)
+	if err := driver.UnlockFile(l.file, l.path); err != nil {
+		l.file.Close()
+		return err
+	}
+	if err := l.file.Close(); err != nil {
+		return Err.Msg("Failed to close file %q", l.path).Make().Cause(err)
+	}
+	return nil
+}
+
+// Lock acquires an advisory lock on a file that is created if it does not exist. Set blocking to false to return ErrLocked instead of waiting for other holders.
+//
+// Drivers that do not support LockFileSystemDriver use in-process locks that only protect against concurrent access through the same driver instance. Drivers that are not passed as pointer are identified by the FileSystem instead.
+func (fs *FileSystem) Lock(path string, mode LockMode, blocking bool) (*FileLock, errors.Error) {
+	if !fs.canWrite {
+		return nil, ErrNotSupported.Args("Lock").Make()
+	}
+
+	if driver, ok := fs.navDriver.(LockFileSystemDriver); ok {
+		f, err := fs.openLockFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		err = driver.LockFile(f, path, mode, blocking)
+		if err == nil {
+			return &FileLock{fs: fs, path: path, file: f}, nil
+		}
+		f.Close()
+		if !errors.InstanceOf(err, ErrNotSupported) {
+			return nil, err
+		}
+	}
+
+	if !processLocks.lock(processLockKey{fs.processLockOwner(), path}, mode, blocking) {
+		return nil, ErrLocked.Args(path).Make()
+	}
+	return &FileLock{fs: fs, path: path}, nil
+}
+
+// processLockOwner returns the identity of in-process locks. Only pointers are used as map key, because driver values might not be comparable.
+func (fs *FileSystem) processLockOwner() interface{} {
+	if reflect.ValueOf(fs.navDriver).Kind() == reflect.Ptr {
+		return fs.navDriver
+	}
+	return fs
+}
+
+func (fs *FileSystem) openLockFile(path string) (File, errors.Error) {
+	f, err := fs.rwDriver.OpenFile(path, OpenReadOnly)
+	if err == nil {
+		return f, nil
+	}
+	if !errors.InstanceOf(err, ErrFileNotExists) {
+		return nil, err
+	}
+	return fs.rwDriver.OpenFile(path, OpenReadWrite.Create())
+}
+
+// WithLock acquires an exclusive lock on path, waiting for other holders, and releases it when f returns.
+//
+// The lock is held on path itself, which is created empty if it does not exist. f can read and write path while the lock is held on all platforms, because the lock does not cover the file content on Windows.
+func (fs *FileSystem) WithLock(path string, f func() errors.Error) errors.Error {
+	lock, err := fs.Lock(path, LockExclusive, true)
+	if err != nil {
+		return err
+	}
+
+	fErr := f()
+	if err := lock.Unlock(); err != nil && fErr == nil {
+		return err
+	}
+	return fErr
+}
+
+var processLocks = &processLockTable{locks: make(map[processLockKey]*processLock)}
+
+type processLockKey struct {
+	driver interface{}
+	path   string
+}
+
+// processLockTable contains readers-writer locks for drivers without native locking.
+type processLockTable struct {
+	mutex sync.Mutex
+	cond  *sync.Cond
+	locks map[processLockKey]*processLock
+}
+
+type processLock struct {
+	readers   int
+	exclusive bool
+}
+
+func (t *processLockTable) lock(key processLockKey, mode LockMode, blocking bool) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.cond == nil {
+		t.cond = sync.NewCond(&t.mutex)
+	}
+
+	for {
+		l, ok := t.locks[key]
+		if !ok {
+			l = &processLock{}
+			t.locks[key] = l
+		}
+
+		if !l.exclusive && (mode == LockShared || l.readers == 0) {
+			if mode == LockShared {
+				l.readers++
+			} else {
+				l.exclusive = true
+			}
+			return true
+		}
+
+		if !blocking {
+			return false
+		}
+		t.cond.Wait()
+	}
+}
+
+func (t *processLockTable) unlock(key processLockKey) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	l, ok := t.locks[key]
+	if !ok {
+		return
+	}
+	if l.exclusive {
+		l.exclusive = false
+	} else if l.readers > 0 {
+		l.readers--
+	}
+	if !l.exclusive && l.readers == 0 {
+		delete(t.locks, key)
+	}
+	t.cond.Broadcast()
+}
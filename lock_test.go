@@ -0,0 +1,160 @@
+package fs
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLock(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		t.Run("TestLocalDriver", func(t *testing.T) {
+			testLock(t, NewWithDriver(&LocalDriver{Root: tmpDir}))
+		})
+		t.Run("TestProcessLocks", func(t *testing.T) {
+			testLock(t, NewWithDriver(&basicDriver{&LocalDriver{Root: tmpDir}}))
+		})
+		t.Run("TestUncomparableDriver", func(t *testing.T) {
+			testLock(t, NewWithDriver(uncomparableDriver{&LocalDriver{Root: tmpDir}, []string{"foo"}}))
+		})
+		return nil
+	}))
+}
+
+// uncomparableDriver is passed by value and contains a slice, so it cannot be used as map key.
+type uncomparableDriver struct {
+	ReadWriteFileSystemDriver
+	tags []string
+}
+
+func testLock(t *testing.T, fs *FileSystem) {
+	t.Run("TestNonBlocking", func(t *testing.T) {
+		lock, err := fs.Lock("/state.txt", LockExclusive, false)
+		errors.AssertNil(t, err)
+		assertIsFile(t, fs, "/state.txt")
+
+		_, err = fs.Lock("/state.txt", LockExclusive, false)
+		errors.Assert(t, ErrLocked, err)
+		_, err = fs.Lock("/state.txt", LockShared, false)
+		errors.Assert(t, ErrLocked, err)
+		errors.AssertNil(t, lock.Unlock())
+		errors.AssertNil(t, lock.Unlock())
+
+		shared1, err := fs.Lock("/state.txt", LockShared, false)
+		errors.AssertNil(t, err)
+		shared2, err := fs.Lock("/state.txt", LockShared, false)
+		errors.AssertNil(t, err)
+		_, err = fs.Lock("/state.txt", LockExclusive, false)
+		errors.Assert(t, ErrLocked, err)
+		errors.AssertNil(t, shared1.Unlock())
+		_, err = fs.Lock("/state.txt", LockExclusive, false)
+		errors.Assert(t, ErrLocked, err)
+		errors.AssertNil(t, shared2.Unlock())
+
+		lock, err = fs.Lock("/state.txt", LockExclusive, false)
+		errors.AssertNil(t, err)
+		errors.AssertNil(t, lock.Unlock())
+	})
+
+	t.Run("TestBlocking", func(t *testing.T) {
+		lock, err := fs.Lock("/state.txt", LockExclusive, true)
+		errors.AssertNil(t, err)
+
+		acquired := make(chan struct{})
+		go func() {
+			lock, err := fs.Lock("/state.txt", LockShared, true)
+			errors.AssertNil(t, err)
+			close(acquired)
+			lock.Unlock()
+		}()
+
+		select {
+		case <-acquired:
+			assert.Fail(t, "Lock has been acquired while held exclusively")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		errors.AssertNil(t, lock.Unlock())
+		select {
+		case <-acquired:
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "Lock has not been acquired after release")
+		}
+	})
+
+	t.Run("TestWithLock", func(t *testing.T) {
+		errors.AssertNil(t, fs.WriteString("/counter.txt", "0"))
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errors.AssertNil(t, fs.WithLock("/counter.txt.lock", func() errors.Error {
+					content, err := fs.ReadString("/counter.txt")
+					if err != nil {
+						return err
+					}
+					counter, _ := strconv.Atoi(content)
+					// increase the chance of lost updates without locking
+					time.Sleep(time.Millisecond)
+					return fs.WriteString("/counter.txt", strconv.Itoa(counter+1))
+				}))
+			}()
+		}
+		wg.Wait()
+
+		assertFileContent(t, fs, "/counter.txt", "10")
+	})
+
+	t.Run("TestWithLockWriteLocked", func(t *testing.T) {
+		// the locked file itself can be used to store the protected state
+		errors.AssertNil(t, fs.WithLock("/state.txt", func() errors.Error {
+			return fs.WriteString("/state.txt", "updated")
+		}))
+		assertFileContent(t, fs, "/state.txt", "updated")
+	})
+
+	t.Run("TestWithLockError", func(t *testing.T) {
+		errors.Assert(t, ErrNotExists, fs.WithLock("/state.txt", func() errors.Error {
+			return ErrNotExists.Args("/foo").Make()
+		}))
+		lock, err := fs.Lock("/state.txt", LockExclusive, false)
+		errors.AssertNil(t, err)
+		errors.AssertNil(t, lock.Unlock())
+	})
+}
+
+func TestLockWrappedDrivers(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		newQuota := func(driver ReadWriteFileSystemDriver) ReadWriteFileSystemDriver {
+			d, err := NewQuotaDriver(driver, 0, 0)
+			errors.AssertNil(t, err)
+			return d
+		}
+		drivers := map[string]ReadWriteFileSystemDriver{
+			"Quota":   newQuota(&LocalDriver{Root: tmpDir}),
+			"Fault":   NewFaultDriver(&LocalDriver{Root: tmpDir}, 0),
+			"Trace":   NewTraceDriver(&LocalDriver{Root: tmpDir}, &recordingSink{}),
+			"Metrics": NewMetricsDriver(&LocalDriver{Root: tmpDir}, nil),
+			"Nested":  NewTraceDriver(NewFaultDriver(newQuota(&LocalDriver{Root: tmpDir}), 0), &recordingSink{}),
+		}
+		local := NewWithDriver(&LocalDriver{Root: tmpDir})
+
+		for name, driver := range drivers {
+			t.Run("Test"+name, func(t *testing.T) {
+				// locks of wrapped drivers must be visible to other driver instances
+				lock, err := NewWithDriver(driver).Lock("/state.txt", LockExclusive, false)
+				errors.AssertNil(t, err)
+				_, err = local.Lock("/state.txt", LockExclusive, false)
+				errors.Assert(t, ErrLocked, err)
+				errors.AssertNil(t, lock.Unlock())
+			})
+		}
+		return nil
+	}))
+}
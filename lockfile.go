@@ -0,0 +1,319 @@
+package fs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sbreitf1/errors"
+)
+
+var (
+	// ErrLockFileHeld occurs when a lock file is held by another owner and has not become stale yet.
+	ErrLockFileHeld = errors.New("The lock file %q is held by process %d on %q")
+	// ErrLockFileLost occurs when a lock file has been removed or taken over by another owner.
+	ErrLockFileLost = errors.New("The lock file %q has been removed or taken over")
+	// ErrLockFileBusy occurs when a lock file cannot be refreshed, because another process is taking it over at the moment.
+	ErrLockFileBusy = errors.New("The lock file %q is being taken over")
+)
+
+const (
+	// lockFileAttempts denotes how often creating a lock file is retried when it is released concurrently.
+	lockFileAttempts = 10
+)
+
+// LockFileOwner is the content of a lock file.
+type LockFileOwner struct {
+	PID  int    `json:"pid"`
+	Host string `json:"host"`
+	// Timestamp denotes the last refresh of the lock file.
+	Timestamp time.Time `json:"timestamp"`
+	// Token uniquely identifies the holder of the lock file.
+	Token string `json:"token"`
+}
+
+// LockFile is a held lock file that is refreshed periodically until it is released.
+type LockFile struct {
+	fs        *FileSystem
+	path      string
+	ttl       time.Duration
+	owner     LockFileOwner
+	mutex     sync.Mutex
+	released  bool
+	done      chan struct{}
+	lost      chan struct{}
+	closeLost sync.Once
+	wg        sync.WaitGroup
+}
+
+// AcquireLockFile creates a lock file that is exclusive across processes and hosts sharing the file system. It does not rely on native locking and thus works for all drivers that support exclusive file creation.
+//
+// The lock file is refreshed every third of ttl. Lock files that have not been refreshed for ttl are considered stale and are taken over. Taking over and refreshing are guarded by a second lock file with suffix ".break". Returns ErrLockFileHeld if the lock file is held by another owner.
+func (fs *FileSystem) AcquireLockFile(path string, ttl time.Duration) (*LockFile, errors.Error) {
+	if !fs.canWrite {
+		return nil, ErrNotSupported.Args("AcquireLockFile").Make()
+	}
+
+	token, err := newLockFileToken()
+	if err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	owner := LockFileOwner{PID: os.Getpid(), Host: host, Timestamp: time.Now(), Token: token}
+
+	// retry when the lock file is released concurrently, but break a stale lock file only once
+	broken := false
+	for attempt := 1; ; attempt++ {
+		err := fs.createLockFile(path, owner)
+		if err == nil {
+			break
+		}
+		if !errors.InstanceOf(err, ErrAlreadyExists) {
+			if exists, existsErr := fs.Exists(path); existsErr != nil || !exists {
+				return nil, err
+			}
+		}
+
+		current, err := fs.ReadLockFile(path)
+		if err != nil {
+			if !errors.InstanceOf(err, ErrFileNotExists) {
+				return nil, err
+			}
+			if attempt >= lockFileAttempts {
+				return nil, Err.Msg("Failed to acquire lock file %q, because it is acquired and released concurrently", path).Make().Cause(err)
+			}
+			// released in the meantime
+			continue
+		}
+		if broken || !isStaleLockFile(current, ttl) {
+			return nil, ErrLockFileHeld.Args(path, current.PID, current.Host).Make()
+		}
+		if err := fs.breakLockFile(path, current, ttl); err != nil {
+			return nil, err
+		}
+		broken = true
+	}
+
+	lock := &LockFile{fs: fs, path: path, ttl: ttl, owner: owner, done: make(chan struct{}), lost: make(chan struct{})}
+	lock.wg.Add(1)
+	go lock.heartbeat()
+	return lock, nil
+}
+
+func newLockFileToken() (string, errors.Error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", Err.Msg("Failed to generate lock file token").Make().Cause(err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func (fs *FileSystem) createLockFile(path string, owner LockFileOwner) errors.Error {
+	f, err := fs.OpenFile(path, OpenWriteOnly.Create().Exclusive())
+	if err != nil {
+		return err
+	}
+
+	data, _ := json.Marshal(owner)
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		fs.DeleteFile(path)
+		return Err.Msg("Failed to write lock file %q", path).Make().Cause(err)
+	}
+	return fs.CloseWrittenFile(f, path)
+}
+
+// ReadLockFile returns the owner stored in a lock file. Returns ErrFileNotExists if the lock file does not exist.
+func (fs *FileSystem) ReadLockFile(path string) (*LockFileOwner, errors.Error) {
+	data, err := fs.ReadBytes(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var owner LockFileOwner
+	if err := json.Unmarshal(data, &owner); err != nil {
+		// the lock file might be written at the moment, fall back to the modification time
+		fi, statErr := fs.Stat(path)
+		if statErr != nil {
+			if errors.InstanceOf(statErr, ErrNotExists) {
+				// removed in the meantime
+				return nil, ErrFileNotExists.Args(path).Make()
+			}
+			return nil, statErr
+		}
+		return &LockFileOwner{Timestamp: modTime(fi)}, nil
+	}
+	return &owner, nil
+}
+
+func isStaleLockFile(owner *LockFileOwner, ttl time.Duration) bool {
+	if owner.Timestamp.IsZero() {
+		// unknown age is never considered stale to not break active locks
+		return false
+	}
+	return time.Since(owner.Timestamp) > ttl
+}
+
+// lockBreakGuard creates the exclusive lock file with suffix ".break" that guards taking over and refreshing a lock file. Returns false if the guard is held by another process. Guards of crashed processes are removed, but not acquired.
+func (fs *FileSystem) lockBreakGuard(path string, ttl time.Duration) (bool, errors.Error) {
+	breakPath := path + ".break"
+	host, _ := os.Hostname()
+	if err := fs.createLockFile(breakPath, LockFileOwner{PID: os.Getpid(), Host: host, Timestamp: time.Now()}); err != nil {
+		if !errors.InstanceOf(err, ErrAlreadyExists) {
+			return false, err
+		}
+		if breaking, err := fs.ReadLockFile(breakPath); err == nil && isStaleLockFile(breaking, ttl) {
+			fs.DeleteFile(breakPath)
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// breakLockFile removes a stale lock file unless it has been refreshed or taken over in the meantime. Breaking is guarded by lockBreakGuard, so that concurrent processes cannot remove a lock file that has just been acquired or refreshed by another process.
+func (fs *FileSystem) breakLockFile(path string, stale *LockFileOwner, ttl time.Duration) errors.Error {
+	locked, err := fs.lockBreakGuard(path, ttl)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		// another process is breaking the lock file at the moment
+		return ErrLockFileHeld.Args(path, stale.PID, stale.Host).Make()
+	}
+	defer fs.DeleteFile(path + ".break")
+
+	current, err := fs.ReadLockFile(path)
+	if err != nil {
+		if errors.InstanceOf(err, ErrFileNotExists) {
+			return nil
+		}
+		return err
+	}
+	if current.Token != stale.Token || !isStaleLockFile(current, ttl) {
+		return ErrLockFileHeld.Args(path, current.PID, current.Host).Make()
+	}
+	return fs.DeleteFile(path)
+}
+
+// Path returns the path of the lock file.
+func (l *LockFile) Path() string {
+	return l.path
+}
+
+// Owner returns the owner information written to the lock file.
+func (l *LockFile) Owner() LockFileOwner {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.owner
+}
+
+// Lost returns a channel that is closed when the lock file has been removed or taken over by another owner while being held.
+func (l *LockFile) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Refresh updates the timestamp of the lock file to prevent it from becoming stale. It is called periodically by a background routine until the lock is released. Returns ErrLockFileBusy if another process is taking over the lock file at the moment.
+func (l *LockFile) Refresh() errors.Error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.released {
+		return ErrLockFileLost.Args(l.path).Make()
+	}
+
+	// the lock file must not be taken over between verifying the owner and replacing the content
+	locked, err := l.fs.lockBreakGuard(l.path, l.ttl)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return ErrLockFileBusy.Args(l.path).Make()
+	}
+	defer l.fs.DeleteFile(l.path + ".break")
+
+	if err := l.verifyOwner(); err != nil {
+		return err
+	}
+
+	owner := l.owner
+	owner.Timestamp = time.Now()
+	data, _ := json.Marshal(owner)
+	// replace the lock file atomically, so that readers never see partially written content
+	tmpPath := l.path + ".refresh-" + owner.Token
+	if err := l.fs.WriteBytes(tmpPath, data); err != nil {
+		l.fs.DeleteFile(tmpPath)
+		return err
+	}
+	if err := l.fs.MoveFile(tmpPath, l.path); err != nil {
+		l.fs.DeleteFile(tmpPath)
+		return err
+	}
+	l.owner = owner
+	return nil
+}
+
+// Release stops refreshing and removes the lock file. Returns ErrLockFileLost if the lock file has been removed or taken over in the meantime.
+func (l *LockFile) Release() errors.Error {
+	l.mutex.Lock()
+	if l.released {
+		l.mutex.Unlock()
+		return nil
+	}
+	l.released = true
+	close(l.done)
+	l.mutex.Unlock()
+	l.wg.Wait()
+
+	if err := l.verifyOwner(); err != nil {
+		return err
+	}
+	return l.fs.DeleteFile(l.path)
+}
+
+func (l *LockFile) verifyOwner() errors.Error {
+	current, err := l.fs.ReadLockFile(l.path)
+	if err != nil {
+		if errors.InstanceOf(err, ErrFileNotExists) {
+			l.setLost()
+			return ErrLockFileLost.Args(l.path).Make()
+		}
+		return err
+	}
+	if current.Token != l.owner.Token {
+		l.setLost()
+		return ErrLockFileLost.Args(l.path).Make()
+	}
+	return nil
+}
+
+func (l *LockFile) setLost() {
+	l.closeLost.Do(func() {
+		close(l.lost)
+	})
+}
+
+func (l *LockFile) heartbeat() {
+	defer l.wg.Done()
+
+	interval := l.ttl / 3
+	if interval <= 0 {
+		interval = time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-l.lost:
+			return
+		case <-ticker.C:
+			// temporary errors are retried with the next tick
+			l.Refresh()
+		}
+	}
+}
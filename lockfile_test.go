@@ -0,0 +1,165 @@
+package fs
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockFile(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		fs := NewWithDriver(&LocalDriver{Root: tmpDir})
+
+		t.Run("TestAcquire", func(t *testing.T) {
+			lock, err := fs.AcquireLockFile("/test.lock", time.Minute)
+			errors.AssertNil(t, err)
+
+			owner, err := fs.ReadLockFile("/test.lock")
+			errors.AssertNil(t, err)
+			assert.Equal(t, os.Getpid(), owner.PID)
+			assert.Equal(t, lock.Owner().Token, owner.Token)
+
+			_, err = fs.OpenFile("/test.lock", OpenWriteOnly.Create().Exclusive())
+			errors.Assert(t, ErrAlreadyExists, err)
+			_, err = fs.AcquireLockFile("/test.lock", time.Minute)
+			errors.Assert(t, ErrLockFileHeld, err)
+
+			errors.AssertNil(t, lock.Release())
+			errors.AssertNil(t, lock.Release())
+			assertNotExists(t, fs, "/test.lock")
+
+			lock, err = fs.AcquireLockFile("/test.lock", time.Minute)
+			errors.AssertNil(t, err)
+			errors.AssertNil(t, lock.Release())
+		})
+
+		t.Run("TestStale", func(t *testing.T) {
+			data, _ := json.Marshal(LockFileOwner{PID: 1, Host: "crashed", Timestamp: time.Now().Add(-2 * time.Minute), Token: "stale"})
+			errors.AssertNil(t, fs.WriteBytes("/test.lock", data))
+
+			lock, err := fs.AcquireLockFile("/test.lock", time.Minute)
+			errors.AssertNil(t, err)
+			owner, err := fs.ReadLockFile("/test.lock")
+			errors.AssertNil(t, err)
+			assert.Equal(t, lock.Owner().Token, owner.Token)
+			errors.AssertNil(t, lock.Release())
+
+			files, err := fs.ReadDir("/")
+			errors.AssertNil(t, err)
+			assert.Empty(t, files)
+		})
+
+		t.Run("TestBreakGuard", func(t *testing.T) {
+			data, _ := json.Marshal(LockFileOwner{PID: 1, Host: "crashed", Timestamp: time.Now().Add(-2 * time.Minute), Token: "stale"})
+			errors.AssertNil(t, fs.WriteBytes("/test.lock", data))
+
+			// another process is breaking the stale lock file at the moment
+			breaking, _ := json.Marshal(LockFileOwner{PID: 2, Host: "other", Timestamp: time.Now(), Token: "breaking"})
+			errors.AssertNil(t, fs.WriteBytes("/test.lock.break", breaking))
+			_, err := fs.AcquireLockFile("/test.lock", time.Minute)
+			errors.Assert(t, ErrLockFileHeld, err)
+			owner, err := fs.ReadLockFile("/test.lock")
+			errors.AssertNil(t, err)
+			assert.Equal(t, "stale", owner.Token)
+			assertIsFile(t, fs, "/test.lock.break")
+
+			// break files of crashed processes are removed
+			abandoned, _ := json.Marshal(LockFileOwner{PID: 2, Host: "other", Timestamp: time.Now().Add(-2 * time.Minute), Token: "breaking"})
+			errors.AssertNil(t, fs.WriteBytes("/test.lock.break", abandoned))
+			_, err = fs.AcquireLockFile("/test.lock", time.Minute)
+			errors.Assert(t, ErrLockFileHeld, err)
+			assertNotExists(t, fs, "/test.lock.break")
+
+			lock, err := fs.AcquireLockFile("/test.lock", time.Minute)
+			errors.AssertNil(t, err)
+			errors.AssertNil(t, lock.Release())
+			files, err := fs.ReadDir("/")
+			errors.AssertNil(t, err)
+			assert.Empty(t, files)
+		})
+
+		t.Run("TestUnknownAge", func(t *testing.T) {
+			// partially written lock files are not stale while they are modified
+			errors.AssertNil(t, fs.WriteString("/test.lock", "{\"pid\":"))
+			_, err := fs.AcquireLockFile("/test.lock", time.Minute)
+			errors.Assert(t, ErrLockFileHeld, err)
+			errors.AssertNil(t, fs.DeleteFile("/test.lock"))
+		})
+
+		t.Run("TestHeartbeat", func(t *testing.T) {
+			lock, err := fs.AcquireLockFile("/test.lock", 30*time.Millisecond)
+			errors.AssertNil(t, err)
+			acquired := lock.Owner().Timestamp
+
+			time.Sleep(100 * time.Millisecond)
+			_, err = fs.AcquireLockFile("/test.lock", 30*time.Millisecond)
+			errors.Assert(t, ErrLockFileHeld, err)
+			assert.True(t, lock.Owner().Timestamp.After(acquired))
+			errors.AssertNil(t, lock.Release())
+
+			// refreshing does not leave temporary files
+			files, err := fs.ReadDir("/")
+			errors.AssertNil(t, err)
+			assert.Empty(t, files)
+		})
+
+		t.Run("TestRefreshGuard", func(t *testing.T) {
+			lock, err := fs.AcquireLockFile("/test.lock", time.Minute)
+			errors.AssertNil(t, err)
+			acquired := lock.Owner().Timestamp
+
+			// another process is taking over the lock file at the moment
+			breaking, _ := json.Marshal(LockFileOwner{PID: 2, Host: "other", Timestamp: time.Now(), Token: "breaking"})
+			errors.AssertNil(t, fs.WriteBytes("/test.lock.break", breaking))
+			errors.Assert(t, ErrLockFileBusy, lock.Refresh())
+			assert.Equal(t, acquired, lock.Owner().Timestamp)
+			errors.AssertNil(t, fs.DeleteFile("/test.lock.break"))
+
+			errors.AssertNil(t, lock.Refresh())
+			assertNotExists(t, fs, "/test.lock.break")
+			errors.AssertNil(t, lock.Release())
+		})
+
+		t.Run("TestReleasedConcurrently", func(t *testing.T) {
+			// the lock file is released between the failed creation and reading the owner
+			driver := &releasedLockFileDriver{LocalDriver: &LocalDriver{Root: tmpDir}}
+			lock, err := NewWithDriver(driver).AcquireLockFile("/test.lock", time.Minute)
+			errors.AssertNil(t, err)
+			errors.AssertNil(t, lock.Release())
+		})
+
+		t.Run("TestLost", func(t *testing.T) {
+			lock, err := fs.AcquireLockFile("/test.lock", 30*time.Millisecond)
+			errors.AssertNil(t, err)
+			errors.AssertNil(t, fs.DeleteFile("/test.lock"))
+
+			select {
+			case <-lock.Lost():
+			case <-time.After(5 * time.Second):
+				assert.Fail(t, "Lost lock file has not been detected")
+			}
+			errors.Assert(t, ErrLockFileLost, lock.Refresh())
+			errors.Assert(t, ErrLockFileLost, lock.Release())
+			assertNotExists(t, fs, "/test.lock")
+		})
+		return nil
+	}))
+}
+
+// releasedLockFileDriver reports the first created file as existing, like a lock file that is released right after the failed creation.
+type releasedLockFileDriver struct {
+	*LocalDriver
+	failed bool
+}
+
+func (d *releasedLockFileDriver) OpenFile(path string, flags OpenFlags) (File, errors.Error) {
+	if !d.failed && flags.IsWrite() {
+		d.failed = true
+		return nil, ErrAlreadyExists.Args(path).Make()
+	}
+	return d.LocalDriver.OpenFile(path, flags)
+}
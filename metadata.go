@@ -0,0 +1,115 @@
+package fs
+
+import (
+	"os"
+	"time"
+
+	"github.com/sbreitf1/errors"
+)
+
+const (
+	// metadataModeMask contains all mode bits that are transferred by metadata operations.
+	metadataModeMask = os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky
+)
+
+// Owner denotes the numeric user and group id of a file.
+type Owner struct {
+	UID int
+	GID int
+}
+
+// Metadata contains attributes of a file or directory. Nil values denote unknown attributes that are not changed when writing metadata.
+type Metadata struct {
+	// Mode contains the permission bits including setuid, setgid and sticky bits.
+	Mode *os.FileMode
+	// ModTime contains the time of the last modification.
+	ModTime *time.Time
+	// Owner contains the numeric user and group id.
+	Owner *Owner
+	// Xattrs contains the extended attributes by name.
+	Xattrs map[string][]byte
+}
+
+// MetadataFileSystemDriver describes optional functionality for drivers that can read and write file attributes. Drivers should silently ignore attributes that cannot be stored.
+type MetadataFileSystemDriver interface {
+	ReadMetadata(path string) (*Metadata, errors.Error)
+	WriteMetadata(path string, metadata *Metadata) errors.Error
+}
+
+// ReadMetadata returns the attributes of a file or directory. Mode and modification time are read using Stat for drivers that do not support MetadataFileSystemDriver.
+func (fs *FileSystem) ReadMetadata(path string) (*Metadata, errors.Error) {
+	if driver, ok := fs.navDriver.(MetadataFileSystemDriver); ok {
+		metadata, err := driver.ReadMetadata(path)
+		if err == nil || !errors.InstanceOf(err, ErrNotSupported) {
+			return metadata, err
+		}
+	}
+
+	if !fs.canNavigate {
+		return nil, ErrNotSupported.Args("ReadMetadata").Make()
+	}
+
+	fi, err := fs.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := &Metadata{}
+	if ext, ok := fi.(ExtendedFileInfo); ok {
+		mode := ext.Mode() & metadataModeMask
+		modTime := ext.ModTime()
+		metadata.Mode = &mode
+		metadata.ModTime = &modTime
+	}
+	return metadata, nil
+}
+
+// WriteMetadata applies all known attributes to a file or directory. Returns ErrNotSupported if the driver does not implement MetadataFileSystemDriver.
+func (fs *FileSystem) WriteMetadata(path string, metadata *Metadata) errors.Error {
+	driver, ok := fs.navDriver.(MetadataFileSystemDriver)
+	if !ok || !fs.canWrite {
+		return ErrNotSupported.Args("WriteMetadata").Make()
+	}
+
+	return driver.WriteMetadata(path, metadata)
+}
+
+// preservesMetadata returns true when any metadata attribute should be transferred to copied elements.
+func (options *CopyOptions) preservesMetadata() bool {
+	return options.PreserveMode || options.PreserveModTime || options.PreserveOwner || options.PreserveXattrs
+}
+
+// selectMetadata returns a copy of metadata that only contains the attributes to preserve.
+func (options *CopyOptions) selectMetadata(metadata *Metadata) *Metadata {
+	selected := &Metadata{}
+	if options.PreserveMode {
+		selected.Mode = metadata.Mode
+	}
+	if options.PreserveModTime {
+		selected.ModTime = metadata.ModTime
+	}
+	if options.PreserveOwner {
+		selected.Owner = metadata.Owner
+	}
+	if options.PreserveXattrs {
+		selected.Xattrs = metadata.Xattrs
+	}
+	return selected
+}
+
+// CopyMetadata transfers the attributes selected by options from src to dst. Attributes are skipped when the destination driver cannot store them.
+func CopyMetadata(fsSrc *FileSystem, src string, fsDst *FileSystem, dst string, options *CopyOptions) errors.Error {
+	if options == nil || !options.preservesMetadata() {
+		return nil
+	}
+
+	metadata, err := fsSrc.ReadMetadata(src)
+	if err != nil {
+		return err
+	}
+
+	if err := fsDst.WriteMetadata(dst, options.selectMetadata(metadata)); err != nil && !errors.InstanceOf(err, ErrNotSupported) {
+		return err
+	}
+	return nil
+}
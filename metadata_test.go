@@ -0,0 +1,79 @@
+package fs
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/fs/path"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyMetadata(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		fs := NewWithDriver(&LocalDriver{Root: tmpDir})
+		modTime := time.Date(2020, 5, 17, 13, 37, 42, 0, time.UTC)
+		errors.AssertNil(t, fs.CreateDirectory("/src/sub"))
+		errors.AssertNil(t, fs.WriteString("/src/sub/run.sh", "#!/bin/sh"))
+		errors.AssertNil(t, os.Chmod(path.Join(tmpDir, "/src/sub/run.sh"), 0750))
+		errors.AssertNil(t, os.Chtimes(path.Join(tmpDir, "/src/sub/run.sh"), modTime, modTime))
+		errors.AssertNil(t, os.Chmod(path.Join(tmpDir, "/src/sub"), 0700))
+		errors.AssertNil(t, os.Chtimes(path.Join(tmpDir, "/src/sub"), modTime, modTime))
+
+		t.Run("TestCopyFile", func(t *testing.T) {
+			errors.AssertNil(t, fs.CopyFileWithOptions("/src/sub/run.sh", "/run.sh", &CopyOptions{PreserveMode: true, PreserveModTime: true}))
+			assertMetadata(t, tmpDir, "/run.sh", 0750, modTime)
+		})
+
+		t.Run("TestCopyDir", func(t *testing.T) {
+			errors.AssertNil(t, fs.CopyDirWithOptions("/src", "/dst", &CopyOptions{PreserveMode: true, PreserveModTime: true}))
+			assertMetadata(t, tmpDir, "/dst/sub", 0700, modTime)
+			assertMetadata(t, tmpDir, "/dst/sub/run.sh", 0750, modTime)
+		})
+
+		t.Run("TestCopyAllParallel", func(t *testing.T) {
+			errors.AssertNil(t, fs.CreateDirectory("/all"))
+			errors.AssertNil(t, fs.CopyAllWithOptions("/src", "/all", &CopyOptions{PreserveModTime: true, Parallelism: 4}))
+			assertMetadata(t, tmpDir, "/all/sub/run.sh", 0, modTime)
+			assertMetadata(t, tmpDir, "/all/sub", 0, modTime)
+		})
+
+		t.Run("TestNotPreserved", func(t *testing.T) {
+			errors.AssertNil(t, fs.CopyFile("/src/sub/run.sh", "/plain.sh"))
+			fi, err := os.Stat(path.Join(tmpDir, "/plain.sh"))
+			errors.AssertNil(t, err)
+			assert.False(t, fi.ModTime().Equal(modTime))
+		})
+
+		t.Run("TestMetadataNotSupported", func(t *testing.T) {
+			noMetadataFS := NewWithDriver(&basicDriver{&LocalDriver{Root: tmpDir}})
+			metadata, err := noMetadataFS.ReadMetadata("/src/sub/run.sh")
+			errors.AssertNil(t, err)
+			assert.Equal(t, os.FileMode(0750), *metadata.Mode)
+			assert.True(t, modTime.Equal(*metadata.ModTime))
+			assert.Nil(t, metadata.Owner)
+
+			errors.Assert(t, ErrNotSupported, noMetadataFS.WriteMetadata("/src/sub/run.sh", metadata))
+			errors.AssertNil(t, noMetadataFS.CopyFileWithOptions("/src/sub/run.sh", "/degraded.sh", &CopyOptions{PreserveMode: true, PreserveModTime: true}))
+			assertFileContent(t, fs, "/degraded.sh", "#!/bin/sh")
+		})
+		return nil
+	}))
+}
+
+func assertMetadata(t *testing.T, tmpDir, p string, mode os.FileMode, modTime time.Time) {
+	fi, err := os.Stat(path.Join(tmpDir, p))
+	if errors.AssertNil(t, err) {
+		if mode != 0 {
+			assert.Equal(t, mode, fi.Mode().Perm(), "Unexpected mode of %q", p)
+		}
+		assert.True(t, fi.ModTime().Equal(modTime), "Unexpected modification time %v of %q", fi.ModTime(), p)
+	}
+}
+
+// basicDriver only exposes the basic driver functionality without optional interfaces.
+type basicDriver struct {
+	ReadWriteFileSystemDriver
+}
@@ -0,0 +1,395 @@
+package fs
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/sbreitf1/errors"
+)
+
+var (
+	// DefaultLatencyBuckets denotes the upper bounds of the latency histograms recorded by a MetricsDriver.
+	DefaultLatencyBuckets = []time.Duration{
+		100 * time.Microsecond,
+		500 * time.Microsecond,
+		time.Millisecond,
+		5 * time.Millisecond,
+		10 * time.Millisecond,
+		50 * time.Millisecond,
+		100 * time.Millisecond,
+		500 * time.Millisecond,
+		time.Second,
+		5 * time.Second,
+	}
+)
+
+// ErrorKind classifies errors counted by a MetricsDriver.
+type ErrorKind string
+
+const (
+	// ErrorKindNotExists denotes ErrNotExists, ErrFileNotExists and ErrDirectoryNotExists.
+	ErrorKindNotExists ErrorKind = "not-exists"
+	// ErrorKindAlreadyExists denotes ErrAlreadyExists.
+	ErrorKindAlreadyExists ErrorKind = "already-exists"
+	// ErrorKindAccessDenied denotes ErrAccessDenied.
+	ErrorKindAccessDenied ErrorKind = "access-denied"
+	// ErrorKindNotEmpty denotes ErrNotEmpty.
+	ErrorKindNotEmpty ErrorKind = "not-empty"
+	// ErrorKindNotSupported denotes ErrNotSupported.
+	ErrorKindNotSupported ErrorKind = "not-supported"
+	// ErrorKindQuotaExceeded denotes ErrQuotaExceeded.
+	ErrorKindQuotaExceeded ErrorKind = "quota-exceeded"
+	// ErrorKindOther denotes all remaining errors.
+	ErrorKindOther ErrorKind = "other"
+)
+
+// ErrorKindOf returns the kind of an error.
+func ErrorKindOf(err error) ErrorKind {
+	switch {
+	case errors.InstanceOf(err, ErrNotExists), errors.InstanceOf(err, ErrFileNotExists), errors.InstanceOf(err, ErrDirectoryNotExists):
+		return ErrorKindNotExists
+	case errors.InstanceOf(err, ErrAlreadyExists):
+		return ErrorKindAlreadyExists
+	case errors.InstanceOf(err, ErrAccessDenied):
+		return ErrorKindAccessDenied
+	case errors.InstanceOf(err, ErrNotEmpty):
+		return ErrorKindNotEmpty
+	case errors.InstanceOf(err, ErrNotSupported):
+		return ErrorKindNotSupported
+	case errors.InstanceOf(err, ErrQuotaExceeded):
+		return ErrorKindQuotaExceeded
+	default:
+		return ErrorKindOther
+	}
+}
+
+// CallMetrics contains the statistics of a single driver or file function.
+type CallMetrics struct {
+	Count         int64
+	TotalDuration time.Duration
+	// Buckets contains the number of calls per latency bucket. The i-th element counts calls that took longer than the previous bound and at most LatencyBuckets[i], the last element counts all calls exceeding the largest bound.
+	Buckets []int64
+	// Errors contains the number of failed calls per kind.
+	Errors map[ErrorKind]int64
+}
+
+func (m *CallMetrics) clone() *CallMetrics {
+	c := &CallMetrics{Count: m.Count, TotalDuration: m.TotalDuration, Buckets: make([]int64, len(m.Buckets)), Errors: make(map[ErrorKind]int64, len(m.Errors))}
+	copy(c.Buckets, m.Buckets)
+	for kind, count := range m.Errors {
+		c.Errors[kind] = count
+	}
+	return c
+}
+
+// MetricsSnapshot contains the statistics recorded by a MetricsDriver at a specific point in time.
+type MetricsSnapshot struct {
+	// LatencyBuckets contains the upper bounds of the latency buckets of all calls.
+	LatencyBuckets []time.Duration
+	Calls          map[DriverCall]*CallMetrics
+	BytesRead      int64
+	BytesWritten   int64
+}
+
+// MetricsDriver wraps a driver and records counters and latency histograms for all calls. The recorded statistics can be pulled using Snapshot to export them to a monitoring system.
+//
+// Copies always read and write files instead of using native copies of the wrapped driver to count all transferred bytes. Locks, watches, metadata, batched listings, stored checksums and volume information are forwarded to the wrapped driver.
+type MetricsDriver struct {
+	driver       ReadWriteFileSystemDriver
+	buckets      []time.Duration
+	mutex        sync.Mutex
+	calls        map[DriverCall]*CallMetrics
+	bytesRead    int64
+	bytesWritten int64
+}
+
+// NewMetricsDriver returns a driver that wraps driver and records statistics using the given ascending latency bucket bounds. DefaultLatencyBuckets are used if buckets is empty.
+func NewMetricsDriver(driver ReadWriteFileSystemDriver, buckets []time.Duration) *MetricsDriver {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	bucketsCopy := make([]time.Duration, len(buckets))
+	copy(bucketsCopy, buckets)
+	return &MetricsDriver{driver: driver, buckets: bucketsCopy, calls: make(map[DriverCall]*CallMetrics)}
+}
+
+// Snapshot returns a copy of all statistics recorded so far.
+func (d *MetricsDriver) Snapshot() *MetricsSnapshot {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	snapshot := &MetricsSnapshot{
+		LatencyBuckets: make([]time.Duration, len(d.buckets)),
+		Calls:          make(map[DriverCall]*CallMetrics, len(d.calls)),
+		BytesRead:      d.bytesRead,
+		BytesWritten:   d.bytesWritten,
+	}
+	copy(snapshot.LatencyBuckets, d.buckets)
+	for call, metrics := range d.calls {
+		snapshot.Calls[call] = metrics.clone()
+	}
+	return snapshot
+}
+
+// Reset discards all recorded statistics.
+func (d *MetricsDriver) Reset() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.calls = make(map[DriverCall]*CallMetrics)
+	d.bytesRead = 0
+	d.bytesWritten = 0
+}
+
+func (d *MetricsDriver) record(call DriverCall, start time.Time, err error) {
+	duration := time.Since(start)
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	metrics, ok := d.calls[call]
+	if !ok {
+		metrics = &CallMetrics{Buckets: make([]int64, len(d.buckets)+1), Errors: make(map[ErrorKind]int64)}
+		d.calls[call] = metrics
+	}
+
+	metrics.Count++
+	metrics.TotalDuration += duration
+	bucket := len(d.buckets)
+	for i, bound := range d.buckets {
+		if duration <= bound {
+			bucket = i
+			break
+		}
+	}
+	metrics.Buckets[bucket]++
+	if err != nil {
+		metrics.Errors[ErrorKindOf(err)]++
+	}
+}
+
+func (d *MetricsDriver) addBytes(read, written int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.bytesRead += int64(read)
+	d.bytesWritten += int64(written)
+}
+
+// Exists returns true, if the given path is a file or directory.
+func (d *MetricsDriver) Exists(path string) (bool, errors.Error) {
+	start := time.Now()
+	exists, err := d.driver.Exists(path)
+	d.record(CallExists, start, err)
+	return exists, err
+}
+
+// IsFile returns true, if the given path is a file.
+func (d *MetricsDriver) IsFile(path string) (bool, errors.Error) {
+	start := time.Now()
+	isFile, err := d.driver.IsFile(path)
+	d.record(CallIsFile, start, err)
+	return isFile, err
+}
+
+// IsDir returns true, if the given path is a directory.
+func (d *MetricsDriver) IsDir(path string) (bool, errors.Error) {
+	start := time.Now()
+	isDir, err := d.driver.IsDir(path)
+	d.record(CallIsDir, start, err)
+	return isDir, err
+}
+
+// Stat returns file or directory stats for a given path.
+func (d *MetricsDriver) Stat(path string) (FileInfo, errors.Error) {
+	start := time.Now()
+	fi, err := d.driver.Stat(path)
+	d.record(CallStat, start, err)
+	return fi, err
+}
+
+// ReadDir returns all files and directories contained in a directory.
+func (d *MetricsDriver) ReadDir(path string) ([]FileInfo, errors.Error) {
+	start := time.Now()
+	files, err := d.driver.ReadDir(path)
+	d.record(CallReadDir, start, err)
+	return files, err
+}
+
+// OpenFile opens a file instance and returns the handle. Reads, writes and closing of the returned file are recorded as well.
+func (d *MetricsDriver) OpenFile(path string, flags OpenFlags) (File, errors.Error) {
+	start := time.Now()
+	f, err := d.driver.OpenFile(path, flags)
+	d.record(CallOpenFile, start, err)
+	if err != nil {
+		return nil, err
+	}
+	return wrapFile(&metricsFile{File: f, driver: d}, f), nil
+}
+
+// CreateDirectory creates a new directory and all parent directories if they do not exist.
+func (d *MetricsDriver) CreateDirectory(path string) errors.Error {
+	start := time.Now()
+	err := d.driver.CreateDirectory(path)
+	d.record(CallCreateDirectory, start, err)
+	return err
+}
+
+// DeleteFile deletes a file.
+func (d *MetricsDriver) DeleteFile(path string) errors.Error {
+	start := time.Now()
+	err := d.driver.DeleteFile(path)
+	d.record(CallDeleteFile, start, err)
+	return err
+}
+
+// DeleteDirectory deletes a directory.
+func (d *MetricsDriver) DeleteDirectory(path string, recursive bool) errors.Error {
+	start := time.Now()
+	err := d.driver.DeleteDirectory(path, recursive)
+	d.record(CallDeleteDirectory, start, err)
+	return err
+}
+
+// MoveFile moves a file to a new location.
+func (d *MetricsDriver) MoveFile(src, dst string) errors.Error {
+	start := time.Now()
+	err := d.driver.MoveFile(src, dst)
+	d.record(CallMoveFile, start, err)
+	return err
+}
+
+// MoveDir moves a directory to a new location.
+func (d *MetricsDriver) MoveDir(src, dst string) errors.Error {
+	start := time.Now()
+	err := d.driver.MoveDir(src, dst)
+	d.record(CallMoveDir, start, err)
+	return err
+}
+
+// ListDir returns a cursor to list the content of a directory in batches. Only the creation of the cursor is recorded.
+func (d *MetricsDriver) ListDir(path string) (DirCursor, errors.Error) {
+	lister, ok := d.driver.(DirLister)
+	if !ok {
+		return nil, ErrNotSupported.Args("ListDir").Make()
+	}
+	start := time.Now()
+	cursor, err := lister.ListDir(path)
+	d.record(CallListDir, start, err)
+	return cursor, err
+}
+
+// LockFile acquires an advisory lock on a file opened by this driver.
+func (d *MetricsDriver) LockFile(f File, path string, mode LockMode, blocking bool) errors.Error {
+	driver, ok := d.driver.(LockFileSystemDriver)
+	if !ok {
+		return ErrNotSupported.Args("LockFile").Make()
+	}
+	start := time.Now()
+	err := driver.LockFile(unwrapFile(f), path, mode, blocking)
+	d.record(CallLockFile, start, err)
+	return err
+}
+
+// UnlockFile releases an advisory lock on a file opened by this driver.
+func (d *MetricsDriver) UnlockFile(f File, path string) errors.Error {
+	driver, ok := d.driver.(LockFileSystemDriver)
+	if !ok {
+		return ErrNotSupported.Args("UnlockFile").Make()
+	}
+	start := time.Now()
+	err := driver.UnlockFile(unwrapFile(f), path)
+	d.record(CallUnlockFile, start, err)
+	return err
+}
+
+// Watch starts watching a file or directory. Only the start of watching is recorded, not the reported changes.
+func (d *MetricsDriver) Watch(path string, recursive bool) (Watcher, errors.Error) {
+	driver, ok := d.driver.(WatchFileSystemDriver)
+	if !ok {
+		return nil, ErrNotSupported.Args("Watch").Make()
+	}
+	start := time.Now()
+	watcher, err := driver.Watch(path, recursive)
+	d.record(CallWatch, start, err)
+	return watcher, err
+}
+
+// ReadMetadata returns the attributes of a file or directory.
+func (d *MetricsDriver) ReadMetadata(path string) (*Metadata, errors.Error) {
+	driver, ok := d.driver.(MetadataFileSystemDriver)
+	if !ok {
+		return nil, ErrNotSupported.Args("ReadMetadata").Make()
+	}
+	start := time.Now()
+	metadata, err := driver.ReadMetadata(path)
+	d.record(CallReadMetadata, start, err)
+	return metadata, err
+}
+
+// WriteMetadata applies attributes to a file or directory.
+func (d *MetricsDriver) WriteMetadata(path string, metadata *Metadata) errors.Error {
+	driver, ok := d.driver.(MetadataFileSystemDriver)
+	if !ok {
+		return ErrNotSupported.Args("WriteMetadata").Make()
+	}
+	start := time.Now()
+	err := driver.WriteMetadata(path, metadata)
+	d.record(CallWriteMetadata, start, err)
+	return err
+}
+
+// Hash returns the stored checksum of a file.
+func (d *MetricsDriver) Hash(path string, algo HashAlgorithm) (Checksum, errors.Error) {
+	driver, ok := d.driver.(HashFileSystemDriver)
+	if !ok {
+		return nil, ErrNotSupported.Args("Hash").Make()
+	}
+	start := time.Now()
+	checksum, err := driver.Hash(path, algo)
+	d.record(CallHash, start, err)
+	return checksum, err
+}
+
+// VolumeInfo returns information about the volume the given path is located on.
+func (d *MetricsDriver) VolumeInfo(path string) (VolumeStats, errors.Error) {
+	driver, ok := d.driver.(VolumeInfoDriver)
+	if !ok {
+		return VolumeStats{}, ErrNotSupported.Args("VolumeInfo").Make()
+	}
+	start := time.Now()
+	info, err := driver.VolumeInfo(path)
+	d.record(CallVolumeInfo, start, err)
+	return info, err
+}
+
+type metricsFile struct {
+	File
+	driver *MetricsDriver
+}
+
+func (f *metricsFile) Read(p []byte) (int, error) {
+	start := time.Now()
+	n, err := f.File.Read(p)
+	f.driver.addBytes(n, 0)
+	if err == io.EOF {
+		f.driver.record(CallRead, start, nil)
+	} else {
+		f.driver.record(CallRead, start, err)
+	}
+	return n, err
+}
+
+func (f *metricsFile) Write(p []byte) (int, error) {
+	start := time.Now()
+	n, err := f.File.Write(p)
+	f.driver.addBytes(0, n)
+	f.driver.record(CallWrite, start, err)
+	return n, err
+}
+
+func (f *metricsFile) Close() error {
+	start := time.Now()
+	err := f.File.Close()
+	f.driver.record(CallClose, start, err)
+	return err
+}
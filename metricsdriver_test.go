@@ -0,0 +1,96 @@
+package fs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsDriver(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		driver := NewMetricsDriver(&LocalDriver{Root: tmpDir}, nil)
+		fs := NewWithDriver(driver)
+
+		t.Run("TestCounters", func(t *testing.T) {
+			defer driver.Reset()
+
+			errors.AssertNil(t, fs.WriteString("/test.txt", "foo bar"))
+			assertFileContent(t, fs, "/test.txt", "foo bar")
+			_, err := fs.Stat("/missing.txt")
+			errors.Assert(t, ErrNotExists, err)
+
+			snapshot := driver.Snapshot()
+			assert.Equal(t, DefaultLatencyBuckets, snapshot.LatencyBuckets)
+			assert.Equal(t, int64(7), snapshot.BytesWritten)
+			assert.Equal(t, int64(7), snapshot.BytesRead)
+			assert.Equal(t, int64(2), snapshot.Calls[CallOpenFile].Count)
+			assert.Equal(t, int64(2), snapshot.Calls[CallClose].Count)
+			assert.Equal(t, int64(1), snapshot.Calls[CallWrite].Count)
+			assert.Empty(t, snapshot.Calls[CallRead].Errors)
+
+			stat := snapshot.Calls[CallStat]
+			assert.Equal(t, map[ErrorKind]int64{ErrorKindNotExists: 1}, stat.Errors)
+			var bucketSum int64
+			for _, count := range stat.Buckets {
+				bucketSum += count
+			}
+			assert.Equal(t, stat.Count, bucketSum)
+			assert.Len(t, stat.Buckets, len(DefaultLatencyBuckets)+1)
+		})
+
+		t.Run("TestSnapshotIsCopy", func(t *testing.T) {
+			defer driver.Reset()
+
+			_, err := fs.Stat("/test.txt")
+			errors.AssertNil(t, err)
+			snapshot := driver.Snapshot()
+			_, err = fs.Stat("/test.txt")
+			errors.AssertNil(t, err)
+			assert.Equal(t, int64(1), snapshot.Calls[CallStat].Count)
+			assert.Equal(t, int64(2), driver.Snapshot().Calls[CallStat].Count)
+		})
+
+		t.Run("TestReset", func(t *testing.T) {
+			_, err := fs.ReadDir("/")
+			errors.AssertNil(t, err)
+			driver.Reset()
+			snapshot := driver.Snapshot()
+			assert.Empty(t, snapshot.Calls)
+			assert.Equal(t, int64(0), snapshot.BytesRead)
+		})
+
+		t.Run("TestOptionalInterfaces", func(t *testing.T) {
+			defer driver.Reset()
+
+			errors.AssertNil(t, fs.WithLock("/test.txt", func() errors.Error {
+				_, err := fs.ReadMetadata("/test.txt")
+				return err
+			}))
+			snapshot := driver.Snapshot()
+			assert.Equal(t, int64(1), snapshot.Calls[CallLockFile].Count)
+			assert.Empty(t, snapshot.Calls[CallLockFile].Errors)
+			assert.Equal(t, int64(1), snapshot.Calls[CallUnlockFile].Count)
+			assert.Equal(t, int64(1), snapshot.Calls[CallReadMetadata].Count)
+		})
+
+		t.Run("TestBuckets", func(t *testing.T) {
+			d := NewMetricsDriver(NewFaultDriver(&LocalDriver{Root: tmpDir}, 0), []time.Duration{time.Millisecond, time.Hour})
+			d.driver.(*FaultDriver).AddFault(Fault{Call: CallStat, Kind: FaultDelay, Delay: 5 * time.Millisecond})
+
+			_, err := d.Stat("/test.txt")
+			errors.AssertNil(t, err)
+			assert.Equal(t, []int64{0, 1, 0}, d.Snapshot().Calls[CallStat].Buckets)
+		})
+
+		return nil
+	}))
+}
+
+func TestErrorKindOf(t *testing.T) {
+	assert.Equal(t, ErrorKindNotExists, ErrorKindOf(ErrFileNotExists.Args("/foo").Make()))
+	assert.Equal(t, ErrorKindAccessDenied, ErrorKindOf(ErrAccessDenied.Args("/foo").Make()))
+	assert.Equal(t, ErrorKindQuotaExceeded, ErrorKindOf(ErrQuotaExceeded.Args("foo").Make()))
+	assert.Equal(t, ErrorKindOther, ErrorKindOf(Err.Make()))
+}
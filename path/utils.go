@@ -127,3 +127,12 @@ func NoExt(path string) string {
 	ext := Ext(path)
 	return path[:len(path)-len(ext)]
 }
+
+// Match returns true when the path matches a shell pattern. The pattern syntax is the same as for filepath.Match, wildcards do not match path delimiters.
+func Match(pattern, path string) (bool, errors.Error) {
+	matched, err := filepath.Match(pattern, path)
+	if err != nil {
+		return false, Err.Msg("Malformed pattern %q", pattern).Make().Cause(err)
+	}
+	return matched, nil
+}
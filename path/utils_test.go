@@ -252,3 +252,20 @@ func TestNoExt(t *testing.T) {
 	assert.Equal(t, "/home/just-a-file", NoExt("/home/just-a-file"))
 	assert.Equal(t, "/home/Downloads/archive.tar", NoExt("/home/Downloads/archive.tar.gz"))
 }
+
+func TestMatch(t *testing.T) {
+	matched, err := Match("/foo/*.txt", "/foo/bar.txt")
+	errors.AssertNil(t, err)
+	assert.True(t, matched)
+
+	matched, err = Match("/foo/*.txt", "/foo/bar/test.txt")
+	errors.AssertNil(t, err)
+	assert.False(t, matched)
+
+	matched, err = Match("/foo/[a-c]?r", "/foo/bar")
+	errors.AssertNil(t, err)
+	assert.True(t, matched)
+
+	_, err = Match("/foo/[", "/foo/bar")
+	errors.Assert(t, Err, err)
+}
@@ -0,0 +1,192 @@
+package fs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sbreitf1/fs/path"
+
+	"github.com/sbreitf1/errors"
+)
+
+// OperationType denotes the kind of a planned file system operation.
+type OperationType int
+
+const (
+	// OpCreateDirectory creates the directory Path.
+	OpCreateDirectory OperationType = iota
+	// OpCopyFile copies the file Path to Target.
+	OpCopyFile
+	// OpDeleteFile deletes the file Path.
+	OpDeleteFile
+	// OpDeleteDirectory deletes the empty directory Path.
+	OpDeleteDirectory
+	// OpMoveFile moves the file Path to Target.
+	OpMoveFile
+	// OpMoveDir moves the directory Path to Target.
+	OpMoveDir
+)
+
+// String returns a human readable representation of the operation type.
+func (t OperationType) String() string {
+	switch t {
+	case OpCreateDirectory:
+		return "create-dir"
+	case OpCopyFile:
+		return "copy-file"
+	case OpDeleteFile:
+		return "delete-file"
+	case OpDeleteDirectory:
+		return "delete-dir"
+	case OpMoveFile:
+		return "move-file"
+	case OpMoveDir:
+		return "move-dir"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(t))
+	}
+}
+
+// Operation describes a single planned file system operation.
+type Operation struct {
+	Type   OperationType
+	Path   string
+	Target string
+}
+
+// String returns a human readable representation of the operation.
+func (op Operation) String() string {
+	if len(op.Target) > 0 {
+		return fmt.Sprintf("%s %s -> %s", op.Type, op.Path, op.Target)
+	}
+	return fmt.Sprintf("%s %s", op.Type, op.Path)
+}
+
+// Plan contains an ordered list of file system operations that can be reviewed before they are applied.
+type Plan struct {
+	Operations []Operation
+}
+
+// Add appends an operation to the plan.
+func (p *Plan) Add(opType OperationType, path, target string) {
+	p.Operations = append(p.Operations, Operation{opType, path, target})
+}
+
+// String returns all operations separated by line breaks.
+func (p *Plan) String() string {
+	lines := make([]string, len(p.Operations))
+	for i := range p.Operations {
+		lines[i] = p.Operations[i].String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func newPlan() *Plan {
+	return &Plan{Operations: make([]Operation, 0)}
+}
+
+// PlanCleanDir returns the operations performed by CleanDir without applying them. Contents of sub-directories are listed as separate operations.
+func (fs *FileSystem) PlanCleanDir(dir string) (*Plan, errors.Error) {
+	if !fs.canNavigate {
+		return nil, ErrNotSupported.Args("PlanCleanDir").Make()
+	}
+
+	plan := newPlan()
+	if err := fs.planDeleteContent(plan, dir); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// PlanDeleteDirectory returns the operations performed by DeleteDirectory without applying them. Contents of the directory are listed as separate operations for recursive deletion.
+func (fs *FileSystem) PlanDeleteDirectory(dir string, recursive bool) (*Plan, errors.Error) {
+	if !fs.canNavigate {
+		return nil, ErrNotSupported.Args("PlanDeleteDirectory").Make()
+	}
+
+	plan := newPlan()
+	if recursive {
+		if err := fs.planDeleteContent(plan, dir); err != nil {
+			return nil, err
+		}
+	}
+	plan.Add(OpDeleteDirectory, dir, "")
+	return plan, nil
+}
+
+// PlanDeleteContent appends delete operations for all files and directories contained in dir to the plan. Directories are deleted after their content.
+func (fs *FileSystem) PlanDeleteContent(plan *Plan, dir string) errors.Error {
+	if !fs.canNavigate {
+		return ErrNotSupported.Args("PlanDeleteContent").Make()
+	}
+
+	return fs.planDeleteContent(plan, dir)
+}
+
+func (fs *FileSystem) planDeleteContent(plan *Plan, dir string) errors.Error {
+	return fs.Walk(dir, func(dir string, f FileInfo, isRoot bool) errors.Error {
+		if !f.IsDir() {
+			plan.Add(OpDeleteFile, path.Join(dir, f.Name()), "")
+		}
+		return nil
+	}, nil, func(dir string, f FileInfo, isRoot bool) errors.Error {
+		plan.Add(OpDeleteDirectory, path.Join(dir, f.Name()), "")
+		return nil
+	}, &WalkOptions{VisitOrder: OrderLexicographicAsc})
+}
+
+// PlanMoveAll returns the operations performed by MoveAll without applying them.
+func (fs *FileSystem) PlanMoveAll(src, dst string) (*Plan, errors.Error) {
+	if !fs.canNavigate {
+		return nil, ErrNotSupported.Args("PlanMoveAll").Make()
+	}
+
+	files, err := fs.ReadDir(src)
+	if err != nil {
+		return nil, err
+	}
+	Sort(files, OrderLexicographicAsc)
+
+	plan := newPlan()
+	for _, f := range files {
+		if f.IsDir() {
+			plan.Add(OpMoveDir, path.Join(src, f.Name()), path.Join(dst, f.Name()))
+		} else {
+			plan.Add(OpMoveFile, path.Join(src, f.Name()), path.Join(dst, f.Name()))
+		}
+	}
+	return plan, nil
+}
+
+// ApplyPlan performs all operations of a plan in the given order and stops at the first error.
+func (fs *FileSystem) ApplyPlan(plan *Plan) errors.Error {
+	if !fs.canWrite {
+		return ErrNotSupported.Args("ApplyPlan").Make()
+	}
+
+	for _, op := range plan.Operations {
+		if err := fs.applyOperation(op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fs *FileSystem) applyOperation(op Operation) errors.Error {
+	switch op.Type {
+	case OpCreateDirectory:
+		return fs.CreateDirectory(op.Path)
+	case OpCopyFile:
+		return fs.CopyFile(op.Path, op.Target)
+	case OpDeleteFile:
+		return fs.DeleteFile(op.Path)
+	case OpDeleteDirectory:
+		return fs.DeleteDirectory(op.Path, false)
+	case OpMoveFile:
+		return fs.MoveFile(op.Path, op.Target)
+	case OpMoveDir:
+		return fs.MoveDir(op.Path, op.Target)
+	default:
+		return ErrNotSupported.Args(op.Type.String()).Make()
+	}
+}
@@ -0,0 +1,81 @@
+package fs
+
+import (
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlan(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		fs := NewWithDriver(&LocalDriver{Root: tmpDir})
+		errors.AssertNil(t, fs.CreateDirectory("/foo/bar/empty"))
+		errors.AssertNil(t, fs.WriteString("/foo/a.txt", "foo"))
+		errors.AssertNil(t, fs.WriteString("/foo/bar/b.txt", "bar"))
+
+		t.Run("TestPlanCleanDir", func(t *testing.T) {
+			plan, err := fs.PlanCleanDir("/foo")
+			errors.AssertNil(t, err)
+			assert.Equal(t, []Operation{
+				{OpDeleteFile, "/foo/a.txt", ""},
+				{OpDeleteFile, "/foo/bar/b.txt", ""},
+				{OpDeleteDirectory, "/foo/bar/empty", ""},
+				{OpDeleteDirectory, "/foo/bar", ""},
+			}, plan.Operations)
+			assert.Equal(t, "delete-file /foo/a.txt\ndelete-file /foo/bar/b.txt\ndelete-dir /foo/bar/empty\ndelete-dir /foo/bar", plan.String())
+			assertIsFile(t, fs, "/foo/bar/b.txt")
+		})
+
+		t.Run("TestPlanDeleteDirectory", func(t *testing.T) {
+			plan, err := fs.PlanDeleteDirectory("/foo/bar", false)
+			errors.AssertNil(t, err)
+			assert.Equal(t, []Operation{{OpDeleteDirectory, "/foo/bar", ""}}, plan.Operations)
+			errors.Assert(t, ErrNotEmpty, fs.ApplyPlan(plan))
+
+			plan, err = fs.PlanDeleteDirectory("/foo/bar", true)
+			errors.AssertNil(t, err)
+			assert.Equal(t, []Operation{
+				{OpDeleteFile, "/foo/bar/b.txt", ""},
+				{OpDeleteDirectory, "/foo/bar/empty", ""},
+				{OpDeleteDirectory, "/foo/bar", ""},
+			}, plan.Operations)
+		})
+
+		t.Run("TestPlanMoveAll", func(t *testing.T) {
+			errors.AssertNil(t, fs.CreateDirectory("/dst"))
+			plan, err := fs.PlanMoveAll("/foo", "/dst")
+			errors.AssertNil(t, err)
+			assert.Equal(t, []Operation{
+				{OpMoveFile, "/foo/a.txt", "/dst/a.txt"},
+				{OpMoveDir, "/foo/bar", "/dst/bar"},
+			}, plan.Operations)
+			assert.Equal(t, "move-file /foo/a.txt -> /dst/a.txt\nmove-dir /foo/bar -> /dst/bar", plan.String())
+
+			errors.AssertNil(t, fs.ApplyPlan(plan))
+			assertFileContent(t, fs, "/dst/bar/b.txt", "bar")
+			assertNotExists(t, fs, "/foo/a.txt")
+		})
+
+		t.Run("TestApplyPlan", func(t *testing.T) {
+			plan, err := fs.PlanCleanDir("/dst")
+			errors.AssertNil(t, err)
+			plan.Add(OpCreateDirectory, "/dst/new", "")
+			errors.AssertNil(t, fs.ApplyPlan(plan))
+			files, err := fs.ReadDir("/dst")
+			errors.AssertNil(t, err)
+			if assert.Len(t, files, 1) {
+				assert.Equal(t, "new", files[0].Name())
+			}
+		})
+
+		t.Run("TestApplyUnknownOperation", func(t *testing.T) {
+			plan := &Plan{}
+			plan.Add(OperationType(42), "/dst/new", "")
+			err := fs.ApplyPlan(plan)
+			errors.Assert(t, ErrNotSupported, err)
+			assert.Contains(t, err.Error(), "unknown(42)")
+		})
+		return nil
+	}))
+}
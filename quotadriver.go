@@ -0,0 +1,436 @@
+package fs
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/sbreitf1/fs/path"
+
+	"github.com/sbreitf1/errors"
+)
+
+var (
+	// ErrQuotaExceeded occurs when a write operation would exceed the quota of a QuotaDriver.
+	ErrQuotaExceeded = errors.New("Quota exceeded: %s")
+)
+
+// QuotaDriver wraps a driver and limits the total size and number of files contained in the wrapped tree. Writes that would exceed the quota fail with ErrQuotaExceeded.
+//
+// Usage is computed once on creation and tracked for all operations performed through the driver afterwards. Changes made by other means are only reflected after calling Recalculate. Native copies of the wrapped driver are hidden, because they would bypass the accounting. All other optional interfaces are forwarded.
+type QuotaDriver struct {
+	driver   ReadWriteFileSystemDriver
+	maxBytes int64
+	maxFiles int
+
+	// opMutex serializes operations that check for existing elements before changing them, so concurrent creations are only accounted once.
+	opMutex sync.Mutex
+
+	mutex     sync.Mutex
+	usedBytes int64
+	fileCount int
+}
+
+// NewQuotaDriver returns a driver that wraps driver and limits the wrapped tree to maxBytes and maxFiles. Use values less or equal to zero to not limit the corresponding measure.
+//
+// The whole tree of driver is walked to compute the usage, so driver must be restricted to the accounted directory. Returns ErrNotSupported for a LocalDriver without Root, which would account the entire host file system.
+func NewQuotaDriver(driver ReadWriteFileSystemDriver, maxBytes int64, maxFiles int) (*QuotaDriver, errors.Error) {
+	if local, ok := driver.(*LocalDriver); ok && len(local.Root) == 0 {
+		return nil, ErrNotSupported.Msg("QuotaDriver requires a LocalDriver with Root").Make()
+	}
+
+	d := &QuotaDriver{driver: driver, maxBytes: maxBytes, maxFiles: maxFiles}
+	if err := d.Recalculate(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Recalculate walks the wrapped tree to determine the current usage.
+func (d *QuotaDriver) Recalculate() errors.Error {
+	stats, err := NewWithDriver(d.driver).DiskUsage("/", &DiskUsageOptions{LargestFilesCount: -1})
+	if err != nil {
+		return err
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.usedBytes = stats.TotalBytes
+	d.fileCount = stats.FileCount
+	return nil
+}
+
+// Usage returns the currently used number of bytes and files.
+func (d *QuotaDriver) Usage() (int64, int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.usedBytes, d.fileCount
+}
+
+// reserveBytes accounts n additional bytes. Non-positive values are ignored.
+func (d *QuotaDriver) reserveBytes(n int64) errors.Error {
+	if n <= 0 {
+		return nil
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.maxBytes > 0 && d.usedBytes+n > d.maxBytes {
+		return ErrQuotaExceeded.Args(fmt.Sprintf("maximum of %d bytes", d.maxBytes)).Make()
+	}
+	d.usedBytes += n
+	return nil
+}
+
+func (d *QuotaDriver) reserveFile() errors.Error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.maxFiles > 0 && d.fileCount+1 > d.maxFiles {
+		return ErrQuotaExceeded.Args(fmt.Sprintf("maximum of %d files", d.maxFiles)).Make()
+	}
+	d.fileCount++
+	return nil
+}
+
+func (d *QuotaDriver) release(bytes int64, files int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.usedBytes -= bytes
+	d.fileCount -= files
+}
+
+// usage returns the accounted bytes and files of an existing file or directory. Non-existing elements have no usage.
+func (d *QuotaDriver) usage(p string) (int64, int, errors.Error) {
+	fi, err := d.driver.Stat(p)
+	if err != nil {
+		if errors.InstanceOf(err, ErrNotExists) || errors.InstanceOf(err, ErrFileNotExists) {
+			return 0, 0, nil
+		}
+		return 0, 0, err
+	}
+	if !fi.IsDir() {
+		return fi.Size(), 1, nil
+	}
+
+	stats, err := NewWithDriver(d.driver).DiskUsage(p, &DiskUsageOptions{LargestFilesCount: -1})
+	if err != nil {
+		return 0, 0, err
+	}
+	return stats.TotalBytes, stats.FileCount, nil
+}
+
+// fileSize returns the size of an existing file or false if the file does not exist.
+func (d *QuotaDriver) fileSize(path string) (int64, bool, errors.Error) {
+	fi, err := d.driver.Stat(path)
+	if err != nil {
+		if errors.InstanceOf(err, ErrNotExists) || errors.InstanceOf(err, ErrFileNotExists) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	if fi.IsDir() {
+		return 0, false, nil
+	}
+	return fi.Size(), true, nil
+}
+
+// Exists returns true, if the given path is a file or directory.
+func (d *QuotaDriver) Exists(path string) (bool, errors.Error) {
+	return d.driver.Exists(path)
+}
+
+// IsFile returns true, if the given path is a file.
+func (d *QuotaDriver) IsFile(path string) (bool, errors.Error) {
+	return d.driver.IsFile(path)
+}
+
+// IsDir returns true, if the given path is a directory.
+func (d *QuotaDriver) IsDir(path string) (bool, errors.Error) {
+	return d.driver.IsDir(path)
+}
+
+// Stat returns file or directory stats for a given path.
+func (d *QuotaDriver) Stat(path string) (FileInfo, errors.Error) {
+	return d.driver.Stat(path)
+}
+
+// ReadDir returns all files and directories contained in a directory.
+func (d *QuotaDriver) ReadDir(path string) ([]FileInfo, errors.Error) {
+	return d.driver.ReadDir(path)
+}
+
+// OpenFile opens a file instance and returns the handle. Created files are counted regardless of the access mode, files opened for writing account all written data.
+func (d *QuotaDriver) OpenFile(path string, flags OpenFlags) (File, errors.Error) {
+	create := int(flags)&os.O_CREATE != 0
+	if !flags.IsWrite() && !create {
+		return d.driver.OpenFile(path, flags)
+	}
+
+	d.opMutex.Lock()
+	defer d.opMutex.Unlock()
+
+	size, exists, err := d.fileSize(path)
+	if err != nil {
+		return nil, err
+	}
+	created := !exists && create
+	if created {
+		if err := d.reserveFile(); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := d.driver.OpenFile(path, flags)
+	if err != nil {
+		if created {
+			d.release(0, 1)
+		}
+		return nil, err
+	}
+	if !flags.IsWrite() {
+		return f, nil
+	}
+
+	if exists && int(flags)&os.O_TRUNC != 0 {
+		d.release(size, 0)
+		size = 0
+	}
+	return wrapFile(&quotaFile{File: f, driver: d, size: size, append: int(flags)&os.O_APPEND != 0}, f), nil
+}
+
+// CreateDirectory creates a new directory and all parent directories if they do not exist.
+func (d *QuotaDriver) CreateDirectory(path string) errors.Error {
+	return d.driver.CreateDirectory(path)
+}
+
+// DeleteFile deletes a file and releases its usage.
+func (d *QuotaDriver) DeleteFile(path string) errors.Error {
+	d.opMutex.Lock()
+	defer d.opMutex.Unlock()
+
+	size, exists, err := d.fileSize(path)
+	if err != nil {
+		return err
+	}
+	if err := d.driver.DeleteFile(path); err != nil {
+		return err
+	}
+	if exists {
+		d.release(size, 1)
+	}
+	return nil
+}
+
+// DeleteDirectory deletes a directory and releases the usage of all contained files.
+func (d *QuotaDriver) DeleteDirectory(path string, recursive bool) errors.Error {
+	d.opMutex.Lock()
+	defer d.opMutex.Unlock()
+
+	var stats *DiskUsageStats
+	if recursive {
+		var err errors.Error
+		stats, err = NewWithDriver(d.driver).DiskUsage(path, &DiskUsageOptions{LargestFilesCount: -1})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := d.driver.DeleteDirectory(path, recursive); err != nil {
+		return err
+	}
+	if stats != nil {
+		d.release(stats.TotalBytes, stats.FileCount)
+	}
+	return nil
+}
+
+// MoveFile moves a file to a new location. The usage of an overwritten file is released.
+func (d *QuotaDriver) MoveFile(src, dst string) errors.Error {
+	return d.move(src, dst, d.driver.MoveFile)
+}
+
+// MoveDir moves a directory to a new location. The usage of an overwritten target is released.
+func (d *QuotaDriver) MoveDir(src, dst string) errors.Error {
+	return d.move(src, dst, d.driver.MoveDir)
+}
+
+// move calls moveFunc and releases the usage of an overwritten target. Moves inside the wrapped tree do not change the usage otherwise.
+func (d *QuotaDriver) move(src, dst string, moveFunc func(src, dst string) errors.Error) errors.Error {
+	d.opMutex.Lock()
+	defer d.opMutex.Unlock()
+
+	// moving an element onto itself does not overwrite anything
+	if path.Clean(src) == path.Clean(dst) {
+		return moveFunc(src, dst)
+	}
+
+	bytes, files, err := d.usage(dst)
+	if err != nil {
+		return err
+	}
+	if err := moveFunc(src, dst); err != nil {
+		return err
+	}
+	d.release(bytes, files)
+	return nil
+}
+
+// ListDir returns a cursor to list the content of a directory in batches.
+func (d *QuotaDriver) ListDir(path string) (DirCursor, errors.Error) {
+	lister, ok := d.driver.(DirLister)
+	if !ok {
+		return nil, ErrNotSupported.Args("ListDir").Make()
+	}
+	return lister.ListDir(path)
+}
+
+// LockFile acquires an advisory lock on a file opened by this driver.
+func (d *QuotaDriver) LockFile(f File, path string, mode LockMode, blocking bool) errors.Error {
+	driver, ok := d.driver.(LockFileSystemDriver)
+	if !ok {
+		return ErrNotSupported.Args("LockFile").Make()
+	}
+	return driver.LockFile(unwrapFile(f), path, mode, blocking)
+}
+
+// UnlockFile releases an advisory lock on a file opened by this driver.
+func (d *QuotaDriver) UnlockFile(f File, path string) errors.Error {
+	driver, ok := d.driver.(LockFileSystemDriver)
+	if !ok {
+		return ErrNotSupported.Args("UnlockFile").Make()
+	}
+	return driver.UnlockFile(unwrapFile(f), path)
+}
+
+// Watch starts watching a file or directory.
+func (d *QuotaDriver) Watch(path string, recursive bool) (Watcher, errors.Error) {
+	driver, ok := d.driver.(WatchFileSystemDriver)
+	if !ok {
+		return nil, ErrNotSupported.Args("Watch").Make()
+	}
+	return driver.Watch(path, recursive)
+}
+
+// ReadMetadata returns the attributes of a file or directory.
+func (d *QuotaDriver) ReadMetadata(path string) (*Metadata, errors.Error) {
+	driver, ok := d.driver.(MetadataFileSystemDriver)
+	if !ok {
+		return nil, ErrNotSupported.Args("ReadMetadata").Make()
+	}
+	return driver.ReadMetadata(path)
+}
+
+// WriteMetadata applies attributes to a file or directory.
+func (d *QuotaDriver) WriteMetadata(path string, metadata *Metadata) errors.Error {
+	driver, ok := d.driver.(MetadataFileSystemDriver)
+	if !ok {
+		return ErrNotSupported.Args("WriteMetadata").Make()
+	}
+	return driver.WriteMetadata(path, metadata)
+}
+
+// Hash returns the stored checksum of a file.
+func (d *QuotaDriver) Hash(path string, algo HashAlgorithm) (Checksum, errors.Error) {
+	driver, ok := d.driver.(HashFileSystemDriver)
+	if !ok {
+		return nil, ErrNotSupported.Args("Hash").Make()
+	}
+	return driver.Hash(path, algo)
+}
+
+// VolumeInfo returns information about the volume the given path is located on. The available bytes are limited to the remaining quota.
+func (d *QuotaDriver) VolumeInfo(path string) (VolumeStats, errors.Error) {
+	driver, ok := d.driver.(VolumeInfoDriver)
+	if !ok {
+		return VolumeStats{}, ErrNotSupported.Args("VolumeInfo").Make()
+	}
+	info, err := driver.VolumeInfo(path)
+	if err != nil {
+		return VolumeStats{}, err
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.maxBytes > 0 {
+		remaining := uint64(0)
+		if d.usedBytes < d.maxBytes {
+			remaining = uint64(d.maxBytes - d.usedBytes)
+		}
+		if remaining < info.AvailableBytes {
+			info.AvailableBytes = remaining
+		}
+	}
+	return info, nil
+}
+
+// quotaFile accounts all data written to a file. Concurrent writes to the same file using different handles might be accounted inaccurately.
+type quotaFile struct {
+	File
+	driver *QuotaDriver
+	pos    int64
+	size   int64
+	append bool
+}
+
+func (f *quotaFile) Read(p []byte) (int, error) {
+	n, err := f.File.Read(p)
+	f.pos += int64(n)
+	return n, err
+}
+
+func (f *quotaFile) Write(p []byte) (int, error) {
+	start := f.pos
+	if f.append {
+		start = f.size
+	}
+
+	growth := start + int64(len(p)) - f.size
+	if err := f.driver.reserveBytes(growth); err != nil {
+		return 0, err
+	}
+
+	n, err := f.File.Write(p)
+	if growth > 0 {
+		// release the reserved bytes that have not been written
+		if actual := start + int64(n) - f.size; actual < growth {
+			if actual < 0 {
+				actual = 0
+			}
+			f.driver.release(growth-actual, 0)
+		}
+	}
+	f.pos = start + int64(n)
+	if f.pos > f.size {
+		f.size = f.pos
+	}
+	return n, err
+}
+
+func (f *quotaFile) Seek(offset int64, whence int) (int64, error) {
+	pos, err := f.File.Seek(offset, whence)
+	if err == nil {
+		f.pos = pos
+	}
+	return pos, err
+}
+
+func (f *quotaFile) Truncate(size int64) error {
+	// only called for files that implement Truncater
+	truncater := f.File.(Truncater)
+
+	growth := size - f.size
+	if err := f.driver.reserveBytes(growth); err != nil {
+		return err
+	}
+	if err := truncater.Truncate(size); err != nil {
+		if growth > 0 {
+			f.driver.release(growth, 0)
+		}
+		return err
+	}
+	if growth < 0 {
+		f.driver.release(-growth, 0)
+	}
+	f.size = size
+	return nil
+}
@@ -0,0 +1,204 @@
+package fs
+
+import (
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func assertQuotaUsage(t *testing.T, d *QuotaDriver, expectedBytes int64, expectedFiles int) {
+	bytes, files := d.Usage()
+	assert.Equal(t, expectedBytes, bytes)
+	assert.Equal(t, expectedFiles, files)
+}
+
+func TestQuotaDriver(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		local := NewWithDriver(&LocalDriver{Root: tmpDir})
+		errors.AssertNil(t, local.CreateDirectory("/existing"))
+		errors.AssertNil(t, local.WriteString("/existing/file.txt", "12345"))
+
+		driver, err := NewQuotaDriver(&LocalDriver{Root: tmpDir}, 20, 4)
+		errors.AssertNil(t, err)
+		assertQuotaUsage(t, driver, 5, 1)
+		fs := NewWithDriver(driver)
+
+		t.Run("TestWriteBytes", func(t *testing.T) {
+			errors.AssertNil(t, fs.WriteString("/a.txt", "1234567890"))
+			assertQuotaUsage(t, driver, 15, 2)
+
+			errors.Assert(t, ErrQuotaExceeded, fs.WriteString("/b.txt", "1234567890"))
+			assertQuotaUsage(t, driver, 15, 3)
+			errors.AssertNil(t, fs.DeleteFile("/b.txt"))
+			assertQuotaUsage(t, driver, 15, 2)
+
+			// truncating releases the previous content
+			errors.AssertNil(t, fs.WriteString("/a.txt", "123456789012345"))
+			assertQuotaUsage(t, driver, 20, 2)
+			errors.AssertNil(t, fs.WriteString("/a.txt", "1"))
+			assertQuotaUsage(t, driver, 6, 2)
+		})
+
+		t.Run("TestOverwriteAndAppend", func(t *testing.T) {
+			f, err := fs.OpenFile("/a.txt", OpenReadWrite)
+			errors.AssertNil(t, err)
+			_, seekErr := f.Seek(0, io.SeekStart)
+			assert.NoError(t, seekErr)
+			_, writeErr := f.Write([]byte("abc"))
+			assert.NoError(t, writeErr)
+			assert.NoError(t, f.Close())
+			assertQuotaUsage(t, driver, 8, 2)
+
+			f, err = fs.OpenFile("/a.txt", OpenWriteOnly.Append())
+			errors.AssertNil(t, err)
+			_, writeErr = f.Write([]byte("1234567890"))
+			assert.NoError(t, writeErr)
+			_, writeErr = f.Write([]byte("123"))
+			assert.True(t, errors.InstanceOf(writeErr, ErrQuotaExceeded))
+			assert.NoError(t, f.Close())
+			assertQuotaUsage(t, driver, 18, 2)
+			assertFileContent(t, fs, "/a.txt", "abc1234567890")
+
+			errors.AssertNil(t, fs.WriteString("/a.txt", ""))
+			assertQuotaUsage(t, driver, 5, 2)
+		})
+
+		t.Run("TestFileCount", func(t *testing.T) {
+			errors.AssertNil(t, fs.WriteString("/c.txt", ""))
+			errors.AssertNil(t, fs.WriteString("/d.txt", ""))
+			assertQuotaUsage(t, driver, 5, 4)
+
+			errors.Assert(t, ErrQuotaExceeded, fs.WriteString("/e.txt", ""))
+			assertNotExists(t, fs, "/e.txt")
+			assertQuotaUsage(t, driver, 5, 4)
+
+			// creating files counts for read-only access as well
+			_, err := fs.OpenFile("/e.txt", OpenReadOnly.Create())
+			errors.Assert(t, ErrQuotaExceeded, err)
+			assertNotExists(t, fs, "/e.txt")
+
+			// overwriting existing files does not count as new file
+			errors.AssertNil(t, fs.WriteString("/d.txt", "1"))
+			assertQuotaUsage(t, driver, 6, 4)
+		})
+
+		t.Run("TestMove", func(t *testing.T) {
+			errors.AssertNil(t, fs.MoveFile("/d.txt", "/c.txt"))
+			assertQuotaUsage(t, driver, 6, 3)
+			errors.AssertNil(t, driver.MoveFile("/c.txt", "/c.txt"))
+			assertQuotaUsage(t, driver, 6, 3)
+			errors.AssertNil(t, driver.MoveFile("/./c.txt", "/c.txt"))
+			assertQuotaUsage(t, driver, 6, 3)
+			errors.AssertNil(t, fs.MoveDir("/existing", "/moved"))
+			assertQuotaUsage(t, driver, 6, 3)
+		})
+
+		t.Run("TestDeleteDirectory", func(t *testing.T) {
+			errors.AssertNil(t, fs.DeleteDirectory("/moved", true))
+			assertQuotaUsage(t, driver, 1, 2)
+		})
+
+		t.Run("TestCopy", func(t *testing.T) {
+			errors.AssertNil(t, local.WriteString("/large.txt", "12345678901234567890"))
+			errors.AssertNil(t, driver.Recalculate())
+			assertQuotaUsage(t, driver, 21, 3)
+
+			// native copies must not bypass the quota
+			errors.Assert(t, ErrQuotaExceeded, fs.CopyFile("/c.txt", "/copy.txt"))
+			errors.AssertNil(t, fs.DeleteFile("/large.txt"))
+			errors.AssertNil(t, fs.CopyFile("/c.txt", "/copy.txt"))
+			assertQuotaUsage(t, driver, 2, 3)
+		})
+
+		return nil
+	}))
+}
+
+// replacingMoveDriver replaces existing targets when moving directories.
+type replacingMoveDriver struct {
+	*LocalDriver
+}
+
+func (d *replacingMoveDriver) MoveDir(src, dst string) errors.Error {
+	if err := d.LocalDriver.DeleteDirectory(dst, true); err != nil && !errors.InstanceOf(err, ErrNotExists) && !errors.InstanceOf(err, ErrFileNotExists) {
+		return err
+	}
+	return d.LocalDriver.MoveDir(src, dst)
+}
+
+func TestQuotaDriverMoveDirOverwrite(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		local := NewWithDriver(&LocalDriver{Root: tmpDir})
+		errors.AssertNil(t, local.CreateDirectory("/src"))
+		errors.AssertNil(t, local.WriteString("/src/a.txt", "12"))
+		errors.AssertNil(t, local.CreateDirectory("/dst/sub"))
+		errors.AssertNil(t, local.WriteString("/dst/b.txt", "123"))
+		errors.AssertNil(t, local.WriteString("/dst/sub/c.txt", "1234"))
+
+		driver, err := NewQuotaDriver(&replacingMoveDriver{&LocalDriver{Root: tmpDir}}, 0, 0)
+		errors.AssertNil(t, err)
+		assertQuotaUsage(t, driver, 9, 3)
+
+		errors.AssertNil(t, driver.MoveDir("/src", "/dst"))
+		assertQuotaUsage(t, driver, 2, 1)
+		return nil
+	}))
+}
+
+func TestQuotaDriverUnrooted(t *testing.T) {
+	_, err := NewQuotaDriver(&LocalDriver{}, 0, 0)
+	errors.Assert(t, ErrNotSupported, err)
+}
+
+func TestQuotaDriverConcurrentCreate(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		driver, err := NewQuotaDriver(&LocalDriver{Root: tmpDir}, 0, 0)
+		errors.AssertNil(t, err)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 16; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				f, err := driver.OpenFile("/test.txt", OpenWriteOnly.Create())
+				if errors.AssertNil(t, err) {
+					f.Close()
+				}
+			}()
+		}
+		wg.Wait()
+		assertQuotaUsage(t, driver, 0, 1)
+		return nil
+	}))
+}
+
+func TestQuotaDriverOptionalInterfaces(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		driver, err := NewQuotaDriver(&LocalDriver{Root: tmpDir}, 10, 0)
+		errors.AssertNil(t, err)
+		fs := NewWithDriver(driver)
+		errors.AssertNil(t, fs.WriteString("/test.txt", "1234"))
+
+		_, isCopyDriver := interface{}(driver).(CopyFileSystemDriver)
+		assert.False(t, isCopyDriver)
+
+		f, err := fs.OpenFile("/test.txt", OpenReadWrite)
+		errors.AssertNil(t, err)
+		errors.AssertNil(t, driver.LockFile(f, "/test.txt", LockExclusive, false))
+		errors.AssertNil(t, driver.UnlockFile(f, "/test.txt"))
+		f.Close()
+
+		info, err := driver.VolumeInfo("/")
+		errors.AssertNil(t, err)
+		assert.Equal(t, uint64(6), info.AvailableBytes)
+
+		driver, err = NewQuotaDriver(&basicDriver{&LocalDriver{Root: tmpDir}}, 0, 0)
+		errors.AssertNil(t, err)
+		_, err = driver.VolumeInfo("/")
+		errors.Assert(t, ErrNotSupported, err)
+		return nil
+	}))
+}
@@ -0,0 +1,148 @@
+package fs
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/sbreitf1/errors"
+)
+
+const (
+	// DefaultResumeChunkSize denotes the size of chunks that are compared to verify partial destination files.
+	DefaultResumeChunkSize = 1024 * 1024
+)
+
+// OpenCopyFiles opens src for reading and dst for writing. If options.Resume is set and dst contains data of a previous copy, both files are positioned at the offset to continue copying from. Otherwise dst is truncated and the returned offset is 0.
+func OpenCopyFiles(fsSrc *FileSystem, src string, fsDst *FileSystem, dst string, options *CopyOptions) (File, File, int64, errors.Error) {
+	reader, err := fsSrc.Open(src)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	if options != nil && options.Resume {
+		writer, offset, err := openResumedFile(reader, fsSrc, src, fsDst, dst, options)
+		if err != nil {
+			reader.Close()
+			return nil, nil, 0, err
+		}
+		if writer != nil {
+			return reader, writer, offset, nil
+		}
+		// verification might have consumed source data before falling back to a full copy
+		if _, err := reader.Seek(0, io.SeekStart); err != nil {
+			reader.Close()
+			return nil, nil, 0, Err.Msg("Failed to seek file %q", src).Make().Cause(err)
+		}
+	}
+
+	writer, err := fsDst.CreateFile(dst)
+	if err != nil {
+		reader.Close()
+		return nil, nil, 0, err
+	}
+	return reader, writer, 0, nil
+}
+
+// openResumedFile returns the destination file positioned at the resume offset or nil if copying must start from the beginning.
+func openResumedFile(reader File, fsSrc *FileSystem, src string, fsDst *FileSystem, dst string, options *CopyOptions) (File, int64, errors.Error) {
+	isFile, err := fsDst.IsFile(dst)
+	if err != nil || !isFile {
+		return nil, 0, err
+	}
+
+	srcInfo, err := fsSrc.Stat(src)
+	if err != nil {
+		return nil, 0, err
+	}
+	dstInfo, err := fsDst.Stat(dst)
+	if err != nil {
+		return nil, 0, err
+	}
+	if dstInfo.Size() == 0 {
+		return nil, 0, nil
+	}
+
+	writer, err := fsDst.OpenFile(dst, OpenReadWrite)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	offset := dstInfo.Size()
+	if len(options.ResumeVerify) > 0 {
+		if offset > srcInfo.Size() {
+			offset = srcInfo.Size()
+		}
+		offset, err = verifiedOffset(reader, writer, offset, options)
+		if err != nil {
+			writer.Close()
+			return nil, 0, err
+		}
+	} else if offset > srcInfo.Size() {
+		// destination belongs to another file
+		offset = 0
+	}
+
+	if offset < dstInfo.Size() {
+		truncater, ok := writer.(Truncater)
+		if !ok || offset == 0 {
+			writer.Close()
+			return nil, 0, nil
+		}
+		if err := truncater.Truncate(offset); err != nil {
+			writer.Close()
+			return nil, 0, Err.Msg("Failed to truncate file %q", dst).Make().Cause(err)
+		}
+	}
+
+	if _, err := reader.Seek(offset, io.SeekStart); err != nil {
+		writer.Close()
+		return nil, 0, Err.Msg("Failed to seek file %q", src).Make().Cause(err)
+	}
+	if _, err := writer.Seek(offset, io.SeekStart); err != nil {
+		writer.Close()
+		return nil, 0, Err.Msg("Failed to seek file %q", dst).Make().Cause(err)
+	}
+	return writer, offset, nil
+}
+
+// verifiedOffset compares both files chunk by chunk up to limit and returns the end of the last matching chunk.
+func verifiedOffset(reader, writer File, limit int64, options *CopyOptions) (int64, errors.Error) {
+	chunkSize := options.ResumeChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultResumeChunkSize
+	}
+
+	var offset int64
+	for offset < limit {
+		size := chunkSize
+		if limit-offset < size {
+			size = limit - offset
+		}
+
+		srcChecksum, err := hashChunk(reader, size, options.ResumeVerify)
+		if err != nil {
+			return 0, err
+		}
+		dstChecksum, err := hashChunk(writer, size, options.ResumeVerify)
+		if err != nil {
+			return 0, err
+		}
+		if !bytes.Equal(srcChecksum, dstChecksum) {
+			break
+		}
+		offset += size
+	}
+	return offset, nil
+}
+
+func hashChunk(r io.Reader, size int64, algo HashAlgorithm) (Checksum, errors.Error) {
+	h, err := algo.New()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := io.CopyN(h, r, size); err != nil {
+		return nil, Err.Msg("Failed to read file").Make().Cause(err)
+	}
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,74 @@
+package fs
+
+import (
+	"testing"
+
+	"github.com/sbreitf1/errors"
+)
+
+func TestCopyResume(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		fs := NewWithDriver(&LocalDriver{Root: tmpDir})
+		errors.AssertNil(t, fs.WriteString("/src.txt", "0123456789"))
+
+		t.Run("TestResumeBySize", func(t *testing.T) {
+			// the existing data is not compared without ResumeVerify
+			errors.AssertNil(t, fs.WriteString("/dst.txt", "abcde"))
+			errors.AssertNil(t, fs.CopyFileWithOptions("/src.txt", "/dst.txt", &CopyOptions{Resume: true}))
+			assertFileContent(t, fs, "/dst.txt", "abcde56789")
+		})
+
+		t.Run("TestResumeLargerDestination", func(t *testing.T) {
+			errors.AssertNil(t, fs.WriteString("/dst.txt", "abcdefghijklmnop"))
+			errors.AssertNil(t, fs.CopyFileWithOptions("/src.txt", "/dst.txt", &CopyOptions{Resume: true}))
+			assertFileContent(t, fs, "/dst.txt", "0123456789")
+		})
+
+		t.Run("TestResumeVerified", func(t *testing.T) {
+			errors.AssertNil(t, fs.WriteString("/dst.txt", "01x34"))
+			errors.AssertNil(t, fs.CopyFileWithOptions("/src.txt", "/dst.txt", &CopyOptions{Resume: true, ResumeVerify: HashSHA256, ResumeChunkSize: 2}))
+			assertFileContent(t, fs, "/dst.txt", "0123456789")
+		})
+
+		t.Run("TestResumeVerifiedFirstChunkMismatch", func(t *testing.T) {
+			errors.AssertNil(t, fs.WriteString("/dst.txt", "x1234"))
+			errors.AssertNil(t, fs.CopyFileWithOptions("/src.txt", "/dst.txt", &CopyOptions{Resume: true, ResumeVerify: HashSHA256, ResumeChunkSize: 2}))
+			assertFileContent(t, fs, "/dst.txt", "0123456789")
+		})
+
+		t.Run("TestResumeVerifiedNoTruncater", func(t *testing.T) {
+			// files of syncCountingDriver cannot be truncated, so the copy restarts from the beginning
+			fsDst := NewWithDriver(&syncCountingDriver{LocalDriver: &LocalDriver{Root: tmpDir}})
+			errors.AssertNil(t, fs.WriteString("/dst.txt", "01x"))
+			errors.AssertNil(t, fsDst.CopyFileWithOptions("/src.txt", "/dst.txt", &CopyOptions{Resume: true, ResumeVerify: HashSHA256, ResumeChunkSize: 1}))
+			assertFileContent(t, fs, "/dst.txt", "0123456789")
+		})
+
+		t.Run("TestResumeVerifiedTruncate", func(t *testing.T) {
+			errors.AssertNil(t, fs.WriteString("/dst.txt", "0123456789abc"))
+			errors.AssertNil(t, fs.CopyFileWithOptions("/src.txt", "/dst.txt", &CopyOptions{Resume: true, ResumeVerify: HashXXHash, ResumeChunkSize: 3}))
+			assertFileContent(t, fs, "/dst.txt", "0123456789")
+		})
+
+		t.Run("TestResumeNoDestination", func(t *testing.T) {
+			errors.AssertNil(t, fs.CopyFileWithOptions("/src.txt", "/new.txt", &CopyOptions{Resume: true, ResumeVerify: HashSHA256}))
+			assertFileContent(t, fs, "/new.txt", "0123456789")
+		})
+
+		t.Run("TestResumeVerifyCopy", func(t *testing.T) {
+			errors.AssertNil(t, fs.WriteString("/dst.txt", "01234"))
+			errors.AssertNil(t, fs.CopyFileWithOptions("/src.txt", "/dst.txt", &CopyOptions{Resume: true, Verify: HashSHA256}))
+			assertFileContent(t, fs, "/dst.txt", "0123456789")
+
+			errors.AssertNil(t, fs.WriteString("/dst.txt", "abcde"))
+			errors.Assert(t, ErrChecksumMismatch, fs.CopyFileWithOptions("/src.txt", "/dst.txt", &CopyOptions{Resume: true, Verify: HashSHA256}))
+		})
+
+		t.Run("TestNoResume", func(t *testing.T) {
+			errors.AssertNil(t, fs.WriteString("/dst.txt", "abcde"))
+			errors.AssertNil(t, fs.CopyFile("/src.txt", "/dst.txt"))
+			assertFileContent(t, fs, "/dst.txt", "0123456789")
+		})
+		return nil
+	}))
+}
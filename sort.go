@@ -2,6 +2,15 @@ package fs
 
 import (
 	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sbreitf1/fs/path"
+
+	"github.com/sbreitf1/errors"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
 )
 
 // FileInfoComparer returns true, when f1 should be displayed before f2.
@@ -33,20 +42,146 @@ var (
 
 	// OrderLexicographicAsc moves elements starting with A to the top of the list.
 	OrderLexicographicAsc = func(f1, f2 FileInfo) int {
-		if f1.Name() < f2.Name() {
+		return compareStrings(f1.Name(), f2.Name())
+	}
+
+	// OrderLexicographicDesc moves elements starting with Z to the top of the list.
+	OrderLexicographicDesc = func(f1, f2 FileInfo) int {
+		return -OrderLexicographicAsc(f1, f2)
+	}
+
+	// OrderCaseInsensitive sorts elements lexicographically ascending ignoring upper and lower case.
+	OrderCaseInsensitive = func(f1, f2 FileInfo) int {
+		return compareStrings(strings.ToLower(f1.Name()), strings.ToLower(f2.Name()))
+	}
+
+	// OrderNatural sorts elements lexicographically ascending but compares sequences of digits by their numeric value, so "file2" is placed before "file10".
+	OrderNatural = func(f1, f2 FileInfo) int {
+		return compareNatural(f1.Name(), f2.Name())
+	}
+
+	// OrderExtension sorts elements by their file extension ignoring upper and lower case. Elements without extension are moved to the top of the list.
+	OrderExtension = func(f1, f2 FileInfo) int {
+		return compareStrings(strings.ToLower(path.Ext(f1.Name())), strings.ToLower(path.Ext(f2.Name())))
+	}
+
+	// OrderSizeAsc moves the smallest elements to the top of the list.
+	OrderSizeAsc = func(f1, f2 FileInfo) int {
+		if f1.Size() < f2.Size() {
 			return -1
-		} else if f1.Name() > f2.Name() {
+		} else if f1.Size() > f2.Size() {
 			return 1
 		}
 		return 0
 	}
 
-	// OrderLexicographicDesc moves elements starting with Z to the top of the list.
-	OrderLexicographicDesc = func(f1, f2 FileInfo) int {
-		return -OrderLexicographicAsc(f1, f2)
+	// OrderSizeDesc moves the largest elements to the top of the list.
+	OrderSizeDesc = func(f1, f2 FileInfo) int {
+		return -OrderSizeAsc(f1, f2)
+	}
+
+	// OrderModTimeAsc moves the oldest elements to the top of the list. Elements that do not implement ExtendedFileInfo are treated as oldest.
+	OrderModTimeAsc = func(f1, f2 FileInfo) int {
+		t1, t2 := modTime(f1), modTime(f2)
+		if t1.Before(t2) {
+			return -1
+		} else if t1.After(t2) {
+			return 1
+		}
+		return 0
+	}
+
+	// OrderModTimeDesc moves the most recently modified elements to the top of the list.
+	OrderModTimeDesc = func(f1, f2 FileInfo) int {
+		return -OrderModTimeAsc(f1, f2)
 	}
 )
 
+func compareStrings(s1, s2 string) int {
+	if s1 < s2 {
+		return -1
+	} else if s1 > s2 {
+		return 1
+	}
+	return 0
+}
+
+func compareNatural(s1, s2 string) int {
+	i, j := 0, 0
+	for i < len(s1) && j < len(s2) {
+		if isDigit(s1[i]) && isDigit(s2[j]) {
+			start1, start2 := i, j
+			for i < len(s1) && isDigit(s1[i]) {
+				i++
+			}
+			for j < len(s2) && isDigit(s2[j]) {
+				j++
+			}
+
+			num1 := strings.TrimLeft(s1[start1:i], "0")
+			num2 := strings.TrimLeft(s2[start2:j], "0")
+			if len(num1) != len(num2) {
+				if len(num1) < len(num2) {
+					return -1
+				}
+				return 1
+			}
+			if order := compareStrings(num1, num2); order != 0 {
+				return order
+			}
+			continue
+		}
+
+		if s1[i] != s2[j] {
+			if s1[i] < s2[j] {
+				return -1
+			}
+			return 1
+		}
+		i++
+		j++
+	}
+
+	if order := compareStrings(s1[i:], s2[j:]); order != 0 {
+		return order
+	}
+	// numerically equal names like "file01" and "file1" are ordered lexicographically
+	return compareStrings(s1, s2)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func modTime(f FileInfo) time.Time {
+	if ext, ok := f.(ExtendedFileInfo); ok {
+		return ext.ModTime()
+	}
+	return time.Time{}
+}
+
+// NewCollationComparer returns a comparer that sorts elements by name using the Unicode collation rules of a language like "de" or "sv-SE". Set ignoreDiacritics to sort letters with accents like their base letters.
+func NewCollationComparer(languageTag string, ignoreDiacritics bool) (FileInfoComparer, errors.Error) {
+	tag, err := language.Parse(languageTag)
+	if err != nil {
+		return nil, Err.Msg("Invalid language tag %q", languageTag).Make().Cause(err)
+	}
+
+	options := make([]collate.Option, 0)
+	if ignoreDiacritics {
+		options = append(options, collate.IgnoreDiacritics)
+	}
+	collator := collate.New(tag, options...)
+
+	// collators keep an internal buffer and must not be used concurrently
+	var mutex sync.Mutex
+	return func(f1, f2 FileInfo) int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return collator.CompareString(f1.Name(), f2.Name())
+	}, nil
+}
+
 // NewCompoundComparer returns a new comparer based on the prioritized list of compare functions. The first comparer has the highest priority.
 func NewCompoundComparer(compareFuncs ...FileInfoComparer) FileInfoComparer {
 	return func(f1, f2 FileInfo) int {
@@ -0,0 +1,90 @@
+package fs
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type testFileInfo struct {
+	name    string
+	size    int64
+	isDir   bool
+	modTime time.Time
+}
+
+func (fi testFileInfo) Name() string       { return fi.name }
+func (fi testFileInfo) Size() int64        { return fi.size }
+func (fi testFileInfo) IsDir() bool        { return fi.isDir }
+func (fi testFileInfo) Mode() os.FileMode  { return os.ModePerm }
+func (fi testFileInfo) ModTime() time.Time { return fi.modTime }
+
+func assertOrder(t *testing.T, cmp FileInfoComparer, files []FileInfo, expected []string) bool {
+	Sort(files, cmp)
+	names := make([]string, len(files))
+	for i := range files {
+		names[i] = files[i].Name()
+	}
+	return assert.Equal(t, expected, names)
+}
+
+func TestOrderNatural(t *testing.T) {
+	files := []FileInfo{testFileInfo{name: "file10"}, testFileInfo{name: "file2"}, testFileInfo{name: "file1b"}, testFileInfo{name: "file01"}, testFileInfo{name: "file"}, testFileInfo{name: "abc100"}}
+	assertOrder(t, OrderNatural, files, []string{"abc100", "file", "file01", "file1b", "file2", "file10"})
+}
+
+func TestOrderCaseInsensitive(t *testing.T) {
+	files := []FileInfo{testFileInfo{name: "b"}, testFileInfo{name: "C"}, testFileInfo{name: "a"}}
+	assertOrder(t, OrderCaseInsensitive, files, []string{"a", "b", "C"})
+}
+
+func TestOrderExtension(t *testing.T) {
+	files := []FileInfo{testFileInfo{name: "b.txt"}, testFileInfo{name: "a.TXT"}, testFileInfo{name: "c.go"}, testFileInfo{name: "readme"}}
+	assertOrder(t, NewCompoundComparer(OrderExtension, OrderLexicographicAsc), files, []string{"readme", "c.go", "a.TXT", "b.txt"})
+}
+
+func TestOrderSize(t *testing.T) {
+	files := []FileInfo{testFileInfo{name: "b", size: 20}, testFileInfo{name: "a", size: 10}, testFileInfo{name: "c", size: 30}}
+	assertOrder(t, OrderSizeAsc, files, []string{"a", "b", "c"})
+	assertOrder(t, OrderSizeDesc, files, []string{"c", "b", "a"})
+}
+
+func TestOrderModTime(t *testing.T) {
+	now := time.Now()
+	files := []FileInfo{testFileInfo{name: "b", modTime: now}, testFileInfo{name: "a", modTime: now.Add(-time.Hour)}, testFileInfo{name: "c", modTime: now.Add(time.Hour)}}
+	assertOrder(t, OrderModTimeAsc, files, []string{"a", "b", "c"})
+	assertOrder(t, OrderModTimeDesc, files, []string{"c", "b", "a"})
+}
+
+func TestCollationComparer(t *testing.T) {
+	cmp, err := NewCollationComparer("de", false)
+	if errors.AssertNil(t, err) {
+		files := []FileInfo{testFileInfo{name: "Zebra"}, testFileInfo{name: "Äpfel"}, testFileInfo{name: "Birne"}, testFileInfo{name: "apfel"}}
+		assertOrder(t, cmp, files, []string{"apfel", "Äpfel", "Birne", "Zebra"})
+	}
+
+	cmp, err = NewCollationComparer("sv", false)
+	if errors.AssertNil(t, err) {
+		files := []FileInfo{testFileInfo{name: "ö"}, testFileInfo{name: "z"}, testFileInfo{name: "a"}}
+		assertOrder(t, cmp, files, []string{"a", "z", "ö"})
+	}
+
+	cmp, err = NewCollationComparer("fr", false)
+	if errors.AssertNil(t, err) {
+		assertOrder(t, cmp, []FileInfo{testFileInfo{name: "élan"}, testFileInfo{name: "Elan"}}, []string{"Elan", "élan"})
+	}
+
+	cmp, err = NewCollationComparer("fr", true)
+	if errors.AssertNil(t, err) {
+		// diacritics are ignored, so lower case letters come first
+		assertOrder(t, cmp, []FileInfo{testFileInfo{name: "Elan"}, testFileInfo{name: "élan"}}, []string{"élan", "Elan"})
+	}
+}
+
+func TestCollationComparerInvalid(t *testing.T) {
+	_, err := NewCollationComparer("not a language", false)
+	errors.Assert(t, Err, err)
+}
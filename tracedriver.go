@@ -0,0 +1,335 @@
+package fs
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sbreitf1/errors"
+)
+
+// TraceEvent describes a single call recorded by a TraceDriver.
+type TraceEvent struct {
+	Call DriverCall
+	Path string
+	// Target contains the destination of moves.
+	Target string
+	// Flags contains the flags passed to OpenFile.
+	Flags OpenFlags
+	// Recursive is set for recursive calls to DeleteDirectory and Watch.
+	Recursive bool
+	Start     time.Time
+	Duration  time.Duration
+	// BytesRead and BytesWritten contain the total number of bytes transferred using a file. They are set for CallClose and failed reads and writes.
+	BytesRead    int64
+	BytesWritten int64
+	Err          error
+}
+
+// String returns a human readable representation of the event.
+func (e TraceEvent) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %q", e.Call, e.Path)
+	if len(e.Target) > 0 {
+		fmt.Fprintf(&sb, " -> %q", e.Target)
+	}
+	switch e.Call {
+	case CallOpenFile:
+		fmt.Fprintf(&sb, " flags=%#x", int(e.Flags))
+	case CallDeleteDirectory, CallWatch:
+		fmt.Fprintf(&sb, " recursive=%v", e.Recursive)
+	case CallRead, CallWrite, CallClose:
+		fmt.Fprintf(&sb, " read=%d written=%d", e.BytesRead, e.BytesWritten)
+	}
+	fmt.Fprintf(&sb, " took %s", e.Duration)
+	if e.Err != nil {
+		fmt.Fprintf(&sb, ": %s", e.Err.Error())
+	}
+	return sb.String()
+}
+
+// TraceSink receives all events recorded by a TraceDriver. It must be safe for concurrent use.
+type TraceSink interface {
+	Trace(event TraceEvent)
+}
+
+// TraceSinkFunc is a function that can be used as TraceSink.
+type TraceSinkFunc func(event TraceEvent)
+
+// Trace calls the function with the given event.
+func (f TraceSinkFunc) Trace(event TraceEvent) {
+	f(event)
+}
+
+// NewLogTraceSink returns a sink that prints a line for every event to a logger. The standard logger is used when logger is nil.
+func NewLogTraceSink(logger *log.Logger) TraceSink {
+	return TraceSinkFunc(func(event TraceEvent) {
+		if logger == nil {
+			log.Println(event.String())
+		} else {
+			logger.Println(event.String())
+		}
+	})
+}
+
+// TraceDriver wraps a driver and records every call to a sink. Reads and writes of opened files are summarized when the file is closed, only failing reads and writes are recorded individually.
+//
+// Native copies of the wrapped driver are not used, so that copied data is transferred through traced files. All other optional interfaces are passed to the wrapped driver and fail with ErrNotSupported if it does not implement them.
+type TraceDriver struct {
+	driver ReadWriteFileSystemDriver
+	sink   TraceSink
+}
+
+// NewTraceDriver returns a driver that wraps driver and records all calls to sink.
+func NewTraceDriver(driver ReadWriteFileSystemDriver, sink TraceSink) *TraceDriver {
+	return &TraceDriver{driver: driver, sink: sink}
+}
+
+func (d *TraceDriver) trace(event TraceEvent, err errors.Error) {
+	event.Duration = time.Since(event.Start)
+	if err != nil {
+		event.Err = err
+	}
+	d.sink.Trace(event)
+}
+
+// Exists returns true, if the given path is a file or directory.
+func (d *TraceDriver) Exists(path string) (bool, errors.Error) {
+	event := TraceEvent{Call: CallExists, Path: path, Start: time.Now()}
+	exists, err := d.driver.Exists(path)
+	d.trace(event, err)
+	return exists, err
+}
+
+// IsFile returns true, if the given path is a file.
+func (d *TraceDriver) IsFile(path string) (bool, errors.Error) {
+	event := TraceEvent{Call: CallIsFile, Path: path, Start: time.Now()}
+	isFile, err := d.driver.IsFile(path)
+	d.trace(event, err)
+	return isFile, err
+}
+
+// IsDir returns true, if the given path is a directory.
+func (d *TraceDriver) IsDir(path string) (bool, errors.Error) {
+	event := TraceEvent{Call: CallIsDir, Path: path, Start: time.Now()}
+	isDir, err := d.driver.IsDir(path)
+	d.trace(event, err)
+	return isDir, err
+}
+
+// Stat returns file or directory stats for a given path.
+func (d *TraceDriver) Stat(path string) (FileInfo, errors.Error) {
+	event := TraceEvent{Call: CallStat, Path: path, Start: time.Now()}
+	fi, err := d.driver.Stat(path)
+	d.trace(event, err)
+	return fi, err
+}
+
+// ReadDir returns all files and directories contained in a directory.
+func (d *TraceDriver) ReadDir(path string) ([]FileInfo, errors.Error) {
+	event := TraceEvent{Call: CallReadDir, Path: path, Start: time.Now()}
+	files, err := d.driver.ReadDir(path)
+	d.trace(event, err)
+	return files, err
+}
+
+// OpenFile opens a file instance and returns the handle. The returned file records a summary when it is closed.
+func (d *TraceDriver) OpenFile(path string, flags OpenFlags) (File, errors.Error) {
+	event := TraceEvent{Call: CallOpenFile, Path: path, Flags: flags, Start: time.Now()}
+	f, err := d.driver.OpenFile(path, flags)
+	d.trace(event, err)
+	if err != nil {
+		return nil, err
+	}
+	return wrapFile(&traceFile{File: f, driver: d, path: path, opened: event.Start}, f), nil
+}
+
+// CreateDirectory creates a new directory and all parent directories if they do not exist.
+func (d *TraceDriver) CreateDirectory(path string) errors.Error {
+	event := TraceEvent{Call: CallCreateDirectory, Path: path, Start: time.Now()}
+	err := d.driver.CreateDirectory(path)
+	d.trace(event, err)
+	return err
+}
+
+// DeleteFile deletes a file.
+func (d *TraceDriver) DeleteFile(path string) errors.Error {
+	event := TraceEvent{Call: CallDeleteFile, Path: path, Start: time.Now()}
+	err := d.driver.DeleteFile(path)
+	d.trace(event, err)
+	return err
+}
+
+// DeleteDirectory deletes a directory.
+func (d *TraceDriver) DeleteDirectory(path string, recursive bool) errors.Error {
+	event := TraceEvent{Call: CallDeleteDirectory, Path: path, Recursive: recursive, Start: time.Now()}
+	err := d.driver.DeleteDirectory(path, recursive)
+	d.trace(event, err)
+	return err
+}
+
+// MoveFile moves a file to a new location.
+func (d *TraceDriver) MoveFile(src, dst string) errors.Error {
+	event := TraceEvent{Call: CallMoveFile, Path: src, Target: dst, Start: time.Now()}
+	err := d.driver.MoveFile(src, dst)
+	d.trace(event, err)
+	return err
+}
+
+// MoveDir moves a directory to a new location.
+func (d *TraceDriver) MoveDir(src, dst string) errors.Error {
+	event := TraceEvent{Call: CallMoveDir, Path: src, Target: dst, Start: time.Now()}
+	err := d.driver.MoveDir(src, dst)
+	d.trace(event, err)
+	return err
+}
+
+// ListDir returns a cursor to list the content of a directory in batches. Only the creation of the cursor is recorded.
+func (d *TraceDriver) ListDir(path string) (DirCursor, errors.Error) {
+	lister, ok := d.driver.(DirLister)
+	if !ok {
+		return nil, ErrNotSupported.Args("ListDir").Make()
+	}
+	event := TraceEvent{Call: CallListDir, Path: path, Start: time.Now()}
+	cursor, err := lister.ListDir(path)
+	d.trace(event, err)
+	return cursor, err
+}
+
+// LockFile acquires an advisory lock on a file opened by this driver.
+func (d *TraceDriver) LockFile(f File, path string, mode LockMode, blocking bool) errors.Error {
+	driver, ok := d.driver.(LockFileSystemDriver)
+	if !ok {
+		return ErrNotSupported.Args("LockFile").Make()
+	}
+	event := TraceEvent{Call: CallLockFile, Path: path, Start: time.Now()}
+	err := driver.LockFile(unwrapFile(f), path, mode, blocking)
+	d.trace(event, err)
+	return err
+}
+
+// UnlockFile releases an advisory lock on a file opened by this driver.
+func (d *TraceDriver) UnlockFile(f File, path string) errors.Error {
+	driver, ok := d.driver.(LockFileSystemDriver)
+	if !ok {
+		return ErrNotSupported.Args("UnlockFile").Make()
+	}
+	event := TraceEvent{Call: CallUnlockFile, Path: path, Start: time.Now()}
+	err := driver.UnlockFile(unwrapFile(f), path)
+	d.trace(event, err)
+	return err
+}
+
+// Watch starts watching a file or directory. Only the start of watching is recorded, not the reported changes.
+func (d *TraceDriver) Watch(path string, recursive bool) (Watcher, errors.Error) {
+	driver, ok := d.driver.(WatchFileSystemDriver)
+	if !ok {
+		return nil, ErrNotSupported.Args("Watch").Make()
+	}
+	event := TraceEvent{Call: CallWatch, Path: path, Recursive: recursive, Start: time.Now()}
+	watcher, err := driver.Watch(path, recursive)
+	d.trace(event, err)
+	return watcher, err
+}
+
+// ReadMetadata returns the attributes of a file or directory.
+func (d *TraceDriver) ReadMetadata(path string) (*Metadata, errors.Error) {
+	driver, ok := d.driver.(MetadataFileSystemDriver)
+	if !ok {
+		return nil, ErrNotSupported.Args("ReadMetadata").Make()
+	}
+	event := TraceEvent{Call: CallReadMetadata, Path: path, Start: time.Now()}
+	metadata, err := driver.ReadMetadata(path)
+	d.trace(event, err)
+	return metadata, err
+}
+
+// WriteMetadata applies attributes to a file or directory.
+func (d *TraceDriver) WriteMetadata(path string, metadata *Metadata) errors.Error {
+	driver, ok := d.driver.(MetadataFileSystemDriver)
+	if !ok {
+		return ErrNotSupported.Args("WriteMetadata").Make()
+	}
+	event := TraceEvent{Call: CallWriteMetadata, Path: path, Start: time.Now()}
+	err := driver.WriteMetadata(path, metadata)
+	d.trace(event, err)
+	return err
+}
+
+// Hash returns the stored checksum of a file.
+func (d *TraceDriver) Hash(path string, algo HashAlgorithm) (Checksum, errors.Error) {
+	driver, ok := d.driver.(HashFileSystemDriver)
+	if !ok {
+		return nil, ErrNotSupported.Args("Hash").Make()
+	}
+	event := TraceEvent{Call: CallHash, Path: path, Start: time.Now()}
+	checksum, err := driver.Hash(path, algo)
+	d.trace(event, err)
+	return checksum, err
+}
+
+// VolumeInfo returns information about the volume the given path is located on.
+func (d *TraceDriver) VolumeInfo(path string) (VolumeStats, errors.Error) {
+	driver, ok := d.driver.(VolumeInfoDriver)
+	if !ok {
+		return VolumeStats{}, ErrNotSupported.Args("VolumeInfo").Make()
+	}
+	event := TraceEvent{Call: CallVolumeInfo, Path: path, Start: time.Now()}
+	info, err := driver.VolumeInfo(path)
+	d.trace(event, err)
+	return info, err
+}
+
+type traceFile struct {
+	File
+	driver       *TraceDriver
+	path         string
+	opened       time.Time
+	mutex        sync.Mutex
+	bytesRead    int64
+	bytesWritten int64
+}
+
+func (f *traceFile) Read(p []byte) (int, error) {
+	start := time.Now()
+	n, err := f.File.Read(p)
+	f.mutex.Lock()
+	f.bytesRead += int64(n)
+	f.mutex.Unlock()
+	if err != nil && err != io.EOF {
+		f.traceTransfer(CallRead, start, err)
+	}
+	return n, err
+}
+
+func (f *traceFile) Write(p []byte) (int, error) {
+	start := time.Now()
+	n, err := f.File.Write(p)
+	f.mutex.Lock()
+	f.bytesWritten += int64(n)
+	f.mutex.Unlock()
+	if err != nil {
+		f.traceTransfer(CallWrite, start, err)
+	}
+	return n, err
+}
+
+func (f *traceFile) traceTransfer(call DriverCall, start time.Time, err error) {
+	f.mutex.Lock()
+	event := TraceEvent{Call: call, Path: f.path, Start: start, Duration: time.Since(start), BytesRead: f.bytesRead, BytesWritten: f.bytesWritten, Err: err}
+	f.mutex.Unlock()
+	f.driver.sink.Trace(event)
+}
+
+// Close records the total number of transferred bytes and the time since the file has been opened.
+func (f *traceFile) Close() error {
+	err := f.File.Close()
+	f.mutex.Lock()
+	event := TraceEvent{Call: CallClose, Path: f.path, Start: f.opened, Duration: time.Since(f.opened), BytesRead: f.bytesRead, BytesWritten: f.bytesWritten, Err: err}
+	f.mutex.Unlock()
+	f.driver.sink.Trace(event)
+	return err
+}
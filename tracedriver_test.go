@@ -0,0 +1,132 @@
+package fs
+
+import (
+	"bytes"
+	"log"
+	"sync"
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingSink struct {
+	mutex  sync.Mutex
+	events []TraceEvent
+}
+
+func (s *recordingSink) Trace(event TraceEvent) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.events = append(s.events, event)
+}
+
+func (s *recordingSink) reset() []TraceEvent {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	events := s.events
+	s.events = nil
+	return events
+}
+
+func TestTraceDriver(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		sink := &recordingSink{}
+		fs := NewWithDriver(NewTraceDriver(&LocalDriver{Root: tmpDir}, sink))
+
+		t.Run("TestWrite", func(t *testing.T) {
+			errors.AssertNil(t, fs.WriteString("/test.txt", "foo bar"))
+
+			events := sink.reset()
+			if assert.Len(t, events, 2) {
+				assert.Equal(t, CallOpenFile, events[0].Call)
+				assert.Equal(t, "/test.txt", events[0].Path)
+				assert.Equal(t, OpenReadWrite.Create().Truncate(), events[0].Flags)
+				assert.Nil(t, events[0].Err)
+
+				assert.Equal(t, CallClose, events[1].Call)
+				assert.Equal(t, int64(7), events[1].BytesWritten)
+				assert.Equal(t, int64(0), events[1].BytesRead)
+			}
+		})
+
+		t.Run("TestRead", func(t *testing.T) {
+			assertFileContent(t, fs, "/test.txt", "foo bar")
+
+			events := sink.reset()
+			close := events[len(events)-1]
+			assert.Equal(t, CallClose, close.Call)
+			assert.Equal(t, int64(7), close.BytesRead)
+		})
+
+		t.Run("TestMoveAndDelete", func(t *testing.T) {
+			errors.AssertNil(t, fs.MoveFile("/test.txt", "/moved.txt"))
+			assert.NotNil(t, fs.DeleteFile("/test.txt"))
+
+			events := sink.reset()
+			move := events[len(events)-2]
+			assert.Equal(t, CallMoveFile, move.Call)
+			assert.Equal(t, "/test.txt", move.Path)
+			assert.Equal(t, "/moved.txt", move.Target)
+			assert.Nil(t, move.Err)
+
+			del := events[len(events)-1]
+			assert.Equal(t, CallDeleteFile, del.Call)
+			assert.NotNil(t, del.Err)
+		})
+
+		t.Run("TestOptionalInterfaces", func(t *testing.T) {
+			errors.AssertNil(t, fs.WriteString("/locked.txt", "foo"))
+			sink.reset()
+
+			lock, err := fs.Lock("/locked.txt", LockExclusive, false)
+			errors.AssertNil(t, err)
+			_, err = fs.Lock("/locked.txt", LockExclusive, false)
+			errors.Assert(t, ErrLocked, err)
+			errors.AssertNil(t, lock.Unlock())
+			_, err = fs.ReadMetadata("/locked.txt")
+			errors.AssertNil(t, err)
+
+			calls := make(map[DriverCall]int)
+			var lockErrs []error
+			for _, event := range sink.reset() {
+				calls[event.Call]++
+				if event.Call == CallLockFile && event.Err != nil {
+					lockErrs = append(lockErrs, event.Err)
+				}
+			}
+			assert.Equal(t, 2, calls[CallLockFile])
+			if assert.Len(t, lockErrs, 1) {
+				errors.Assert(t, ErrLocked, lockErrs[0])
+			}
+			assert.Equal(t, 1, calls[CallUnlockFile])
+			assert.Equal(t, 1, calls[CallReadMetadata])
+		})
+
+		t.Run("TestMissingOptionalInterfaces", func(t *testing.T) {
+			fs := NewWithDriver(NewTraceDriver(&basicDriver{&LocalDriver{Root: tmpDir}}, sink))
+			defer sink.reset()
+
+			lock, err := fs.Lock("/locked.txt", LockExclusive, false)
+			errors.AssertNil(t, err)
+			errors.AssertNil(t, lock.Unlock())
+			metadata, err := fs.ReadMetadata("/locked.txt")
+			errors.AssertNil(t, err)
+			assert.NotNil(t, metadata.ModTime)
+			cursor, err := fs.ListDir("/")
+			errors.AssertNil(t, err)
+			errors.AssertNil(t, cursor.Close())
+			errors.AssertNil(t, fs.EnsureFreeSpace("/", 1))
+		})
+
+		t.Run("TestLogSink", func(t *testing.T) {
+			var buf bytes.Buffer
+			fs := NewWithDriver(NewTraceDriver(&LocalDriver{Root: tmpDir}, NewLogTraceSink(log.New(&buf, "", 0))))
+			errors.AssertNil(t, fs.CreateDirectory("/logged"))
+			errors.AssertNil(t, fs.DeleteDirectory("/logged", false))
+			assert.Contains(t, buf.String(), `DeleteDirectory "/logged" recursive=false took`)
+		})
+
+		return nil
+	}))
+}
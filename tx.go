@@ -0,0 +1,444 @@
+package fs
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/sbreitf1/fs/path"
+
+	"github.com/sbreitf1/errors"
+)
+
+var (
+	// ErrTxFinished occurs when using a transaction that has already been committed or rolled back.
+	ErrTxFinished = errors.New("The transaction has already been finished")
+	// ErrRollbackFailed occurs when a failed transaction could not be rolled back completely.
+	ErrRollbackFailed = errors.New("Failed to roll back transaction after error: %s")
+	// ErrTxJournalCorrupted occurs when the journal of an interrupted transaction cannot be read.
+	ErrTxJournalCorrupted = errors.New("The transaction journal %q is corrupted")
+	// ErrTxStagingConflict occurs when the staging directory is located inside a tree that is moved or deleted by the transaction.
+	ErrTxStagingConflict = errors.New("The staging directory %q is located inside %q, which is changed by the transaction")
+)
+
+const (
+	// txDirPrefix denotes the name prefix of transaction directories in the staging directory.
+	txDirPrefix = "tx-"
+	// txJournalName denotes the name of the journal file in a transaction directory.
+	txJournalName = "journal"
+)
+
+type txOpType int
+
+const (
+	txWrite txOpType = iota
+	txMove
+	txMoveAll
+	txDelete
+	txCleanDir
+)
+
+// txJournalEntry describes a single change applied by a transaction. Entries are written to the journal before the change is applied.
+type txJournalEntry struct {
+	Op string `json:"op"`
+	// Path denotes the original location of the changed element.
+	Path string `json:"path"`
+	// Target denotes the staged location for "stage" and the new location for "move".
+	Target string `json:"target,omitempty"`
+}
+
+const (
+	txJournalStage  = "stage"
+	txJournalWrite  = "write"
+	txJournalMove   = "move"
+	txJournalCommit = "commit"
+)
+
+type txOp struct {
+	opType  txOpType
+	path    string
+	target  string
+	content []byte
+}
+
+// Tx queues write, move and delete operations that are applied together by Commit. When an operation fails, all previous changes are rolled back.
+//
+// Overwritten and deleted elements are moved to a staging directory and restored on failure. The staging directory must be located on the same volume to allow fast moves. All changes are recorded in a journal file inside the staging directory that is flushed to stable storage before each change, so that transactions interrupted by a crash can be rolled back using RecoverTx.
+type Tx struct {
+	fs      *FileSystem
+	baseDir string
+	// stagingDir denotes the unique sub-directory of baseDir that is created on commit.
+	stagingDir string
+	ops        []txOp
+	journal    []txJournalEntry
+	stageCount int
+	finished   bool
+}
+
+// NewTx returns a new transaction that uses a unique sub-directory of stagingDir for overwritten and deleted elements.
+func (fs *FileSystem) NewTx(stagingDir string) *Tx {
+	return &Tx{fs: fs, baseDir: stagingDir, ops: make([]txOp, 0)}
+}
+
+// WriteBytes queues writing all bytes to a file.
+func (tx *Tx) WriteBytes(path string, content []byte) {
+	tx.ops = append(tx.ops, txOp{opType: txWrite, path: path, content: content})
+}
+
+// WriteString queues writing a string to a file.
+func (tx *Tx) WriteString(path, content string) {
+	tx.WriteBytes(path, []byte(content))
+}
+
+// Move queues moving a file or directory to a new location. An existing target is replaced.
+func (tx *Tx) Move(src, dst string) {
+	tx.ops = append(tx.ops, txOp{opType: txMove, path: src, target: dst})
+}
+
+// MoveAll queues moving all files and directories contained in src to dst. The content of src is determined when the transaction is committed.
+func (tx *Tx) MoveAll(src, dst string) {
+	tx.ops = append(tx.ops, txOp{opType: txMoveAll, path: src, target: dst})
+}
+
+// Delete queues deleting a file or a directory including its content.
+func (tx *Tx) Delete(path string) {
+	tx.ops = append(tx.ops, txOp{opType: txDelete, path: path})
+}
+
+// CleanDir queues removing all files and directories from a directory. The content of dir is determined when the transaction is committed.
+func (tx *Tx) CleanDir(dir string) {
+	tx.ops = append(tx.ops, txOp{opType: txCleanDir, path: dir})
+}
+
+// Rollback discards all queued operations.
+func (tx *Tx) Rollback() errors.Error {
+	if tx.finished {
+		return ErrTxFinished.Make()
+	}
+	tx.finished = true
+	tx.ops = nil
+	return nil
+}
+
+// Commit applies all queued operations in order. If an operation fails, all previous changes are reverted and the error is returned. ErrRollbackFailed is returned when reverting failed, and ErrTxStagingConflict if the staging directory is located inside a tree changed by the transaction.
+func (tx *Tx) Commit() errors.Error {
+	if tx.finished {
+		return ErrTxFinished.Make()
+	}
+	tx.finished = true
+
+	if !tx.fs.canWrite {
+		return ErrNotSupported.Args("Commit").Make()
+	}
+
+	if err := tx.checkStagingDir(); err != nil {
+		return err
+	}
+
+	// use a random suffix, because clocks might be too coarse to separate concurrent transactions
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return Err.Msg("Failed to generate transaction id").Make().Cause(err)
+	}
+	tx.stagingDir = path.Join(tx.baseDir, fmt.Sprintf("%s%d-%s", txDirPrefix, time.Now().UnixNano(), hex.EncodeToString(id)))
+	if err := tx.fs.CreateDirectory(tx.stagingDir); err != nil {
+		return err
+	}
+	if err := tx.fs.syncDir(tx.baseDir); err != nil {
+		return err
+	}
+
+	for _, op := range tx.ops {
+		if err := tx.apply(op); err != nil {
+			return tx.abort(err)
+		}
+	}
+
+	// staged elements are not restored anymore after the commit has been recorded
+	if err := tx.log(txJournalEntry{Op: txJournalCommit}); err != nil {
+		return tx.abort(err)
+	}
+	// the transaction is committed anyway, a remaining staging directory is removed by RecoverTx
+	tx.fs.DeleteDirectory(tx.stagingDir, true)
+	return nil
+}
+
+// abort rolls back all applied changes after err occurred and returns the error to report from Commit.
+func (tx *Tx) abort(err errors.Error) errors.Error {
+	if rollbackErr := tx.rollback(); rollbackErr != nil {
+		// keep staging directory and journal to allow recovery using RecoverTx
+		return ErrRollbackFailed.Args(err.Error()).Make().Cause(rollbackErr)
+	}
+	tx.fs.DeleteDirectory(tx.stagingDir, true)
+	return err
+}
+
+func (tx *Tx) rollback() errors.Error {
+	if err := tx.fs.undoTxJournal(tx.journal); err != nil {
+		return err
+	}
+	tx.journal = nil
+	return nil
+}
+
+// checkStagingDir returns ErrTxStagingConflict if the staging directory would be moved or deleted by an operation.
+func (tx *Tx) checkStagingDir() errors.Error {
+	for _, op := range tx.ops {
+		var trees []string
+		switch op.opType {
+		case txMove, txMoveAll:
+			trees = []string{op.path, op.target}
+		case txDelete, txCleanDir:
+			trees = []string{op.path}
+		}
+
+		for _, tree := range trees {
+			if isInTree(tx.baseDir, tree) {
+				return ErrTxStagingConflict.Args(tx.baseDir, tree).Make()
+			}
+		}
+	}
+	return nil
+}
+
+// isInTree returns true if p equals root or is located inside root.
+func isInTree(p, root string) bool {
+	p = path.Clean(p)
+	root = path.Clean(root)
+	return p == root || strings.HasPrefix(p, strings.TrimSuffix(root, "/")+"/")
+}
+
+// log appends an entry to the journal file and flushes it to stable storage before the change is applied.
+func (tx *Tx) log(entry txJournalEntry) errors.Error {
+	data, _ := json.Marshal(entry)
+	journalPath := path.Join(tx.stagingDir, txJournalName)
+	f, err := tx.fs.OpenFile(journalPath, OpenWriteOnly.Create().Append())
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return Err.Msg("Failed to write transaction journal").Make().Cause(err)
+	}
+	if syncer, ok := f.(Syncer); ok {
+		if err := syncer.Sync(); err != nil {
+			f.Close()
+			return Err.Msg("Failed to flush transaction journal").Make().Cause(err)
+		}
+	}
+	if err := tx.fs.CloseWrittenFile(f, journalPath); err != nil {
+		return err
+	}
+	if len(tx.journal) == 0 {
+		// persist the directory entry of the new journal file
+		if err := tx.fs.syncDir(tx.stagingDir); err != nil {
+			return err
+		}
+	}
+	tx.journal = append(tx.journal, entry)
+	return nil
+}
+
+// syncDir flushes the entries of a directory to stable storage. Nothing is done for drivers that cannot open directories or return files without Syncer.
+func (fs *FileSystem) syncDir(dir string) errors.Error {
+	f, err := fs.rwDriver.OpenFile(dir, OpenReadOnly)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	if syncer, ok := f.(Syncer); ok {
+		// directory handles cannot be flushed on Windows, where directory entries are journaled by the file system
+		if err := syncer.Sync(); err != nil && runtime.GOOS != "windows" {
+			return Err.Msg("Failed to flush directory %q", dir).Make().Cause(err)
+		}
+	}
+	return nil
+}
+
+func (tx *Tx) apply(op txOp) errors.Error {
+	switch op.opType {
+	case txWrite:
+		return tx.write(op.path, op.content)
+
+	case txMove:
+		return tx.move(op.path, op.target)
+
+	case txMoveAll:
+		files, err := tx.fs.ReadDir(op.path)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if err := tx.move(path.Join(op.path, f.Name()), path.Join(op.target, f.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case txDelete:
+		return tx.stage(op.path)
+
+	case txCleanDir:
+		files, err := tx.fs.ReadDir(op.path)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if err := tx.stage(path.Join(op.path, f.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return ErrNotSupported.Args(fmt.Sprintf("transaction operation %d", op.opType)).Make()
+	}
+}
+
+func (tx *Tx) write(p string, content []byte) errors.Error {
+	exists, err := tx.fs.Exists(p)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if err := tx.stage(p); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.log(txJournalEntry{Op: txJournalWrite, Path: p}); err != nil {
+		return err
+	}
+	return tx.fs.WriteBytes(p, content)
+}
+
+func (tx *Tx) move(src, dst string) errors.Error {
+	exists, err := tx.fs.Exists(dst)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if err := tx.stage(dst); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.log(txJournalEntry{Op: txJournalMove, Path: src, Target: dst}); err != nil {
+		return err
+	}
+	return tx.fs.Move(src, dst)
+}
+
+// stage moves an element to the staging directory and adds a journal entry to restore it.
+func (tx *Tx) stage(p string) errors.Error {
+	tx.stageCount++
+	stagedPath := path.Join(tx.stagingDir, fmt.Sprintf("%d", tx.stageCount))
+	if err := tx.log(txJournalEntry{Op: txJournalStage, Path: p, Target: stagedPath}); err != nil {
+		return err
+	}
+	return tx.fs.Move(p, stagedPath)
+}
+
+// RecoverTx rolls back all transactions that have been interrupted while using stagingDir, e.g. by a crash, and removes their staging data. Transactions that have been committed completely are only cleaned up. It must not be called while other transactions are using stagingDir.
+func (fs *FileSystem) RecoverTx(stagingDir string) errors.Error {
+	if !fs.canWrite {
+		return ErrNotSupported.Args("RecoverTx").Make()
+	}
+
+	files, err := fs.ReadDir(stagingDir)
+	if err != nil {
+		if errors.InstanceOf(err, ErrNotExists) || errors.InstanceOf(err, ErrDirectoryNotExists) {
+			return nil
+		}
+		return err
+	}
+	Sort(files, OrderLexicographicAsc)
+
+	for _, f := range files {
+		if !f.IsDir() || !strings.HasPrefix(f.Name(), txDirPrefix) {
+			continue
+		}
+
+		txDir := path.Join(stagingDir, f.Name())
+		journal, err := fs.readTxJournal(txDir)
+		if err != nil {
+			return err
+		}
+		if len(journal) == 0 || journal[len(journal)-1].Op != txJournalCommit {
+			if err := fs.undoTxJournal(journal); err != nil {
+				return err
+			}
+		}
+		if err := fs.DeleteDirectory(txDir, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readTxJournal returns all entries of the journal in txDir. An incomplete last entry is ignored, because the corresponding change has not been applied.
+func (fs *FileSystem) readTxJournal(txDir string) ([]txJournalEntry, errors.Error) {
+	journalPath := path.Join(txDir, txJournalName)
+	data, err := fs.ReadBytes(journalPath)
+	if err != nil {
+		if errors.InstanceOf(err, ErrNotExists) || errors.InstanceOf(err, ErrFileNotExists) {
+			// interrupted before the first change
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	lines := bytes.Split(data, []byte("\n"))
+	journal := make([]txJournalEntry, 0, len(lines))
+	for i, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		var entry txJournalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			if i == len(lines)-1 {
+				break
+			}
+			return nil, ErrTxJournalCorrupted.Args(journalPath).Make().Cause(err)
+		}
+		journal = append(journal, entry)
+	}
+	return journal, nil
+}
+
+// undoTxJournal reverts all journal entries in reverse order. Entries of changes that have not been applied are skipped, so reverting can be repeated after a failure.
+func (fs *FileSystem) undoTxJournal(journal []txJournalEntry) errors.Error {
+	for i := len(journal) - 1; i >= 0; i-- {
+		entry := journal[i]
+		switch entry.Op {
+		case txJournalWrite:
+			exists, err := fs.Exists(entry.Path)
+			if err != nil {
+				return err
+			}
+			if exists {
+				if err := fs.DeleteFile(entry.Path); err != nil {
+					return err
+				}
+			}
+
+		case txJournalStage, txJournalMove:
+			exists, err := fs.Exists(entry.Target)
+			if err != nil {
+				return err
+			}
+			if exists {
+				if err := fs.Move(entry.Target, entry.Path); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
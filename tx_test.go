@@ -0,0 +1,215 @@
+package fs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTx(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		fs := NewWithDriver(&LocalDriver{Root: tmpDir})
+		errors.AssertNil(t, fs.CreateDirectory("/staging"))
+		errors.AssertNil(t, fs.CreateDirectory("/live/sub"))
+		errors.AssertNil(t, fs.CreateDirectory("/release/sub"))
+		errors.AssertNil(t, fs.WriteString("/live/config.txt", "old config"))
+		errors.AssertNil(t, fs.WriteString("/live/sub/data.txt", "old data"))
+		errors.AssertNil(t, fs.WriteString("/release/sub/data.txt", "new data"))
+		errors.AssertNil(t, fs.WriteString("/release/new.txt", "new file"))
+
+		t.Run("TestTxRollbackOnError", func(t *testing.T) {
+			tx := fs.NewTx("/staging")
+			tx.WriteString("/live/config.txt", "new config")
+			tx.WriteString("/live/created.txt", "created")
+			tx.CleanDir("/live")
+			tx.MoveAll("/release", "/live")
+			tx.Move("/nonexisting.txt", "/live/fail.txt")
+			errors.Assert(t, ErrNotExists, tx.Commit())
+
+			assertFileContent(t, fs, "/live/config.txt", "old config")
+			assertFileContent(t, fs, "/live/sub/data.txt", "old data")
+			assertNotExists(t, fs, "/live/created.txt")
+			assertNotExists(t, fs, "/live/new.txt")
+			assertFileContent(t, fs, "/release/sub/data.txt", "new data")
+			assertFileContent(t, fs, "/release/new.txt", "new file")
+			assertStagingEmpty(t, fs)
+
+			errors.Assert(t, ErrTxFinished, tx.Commit())
+		})
+
+		t.Run("TestTxCommit", func(t *testing.T) {
+			tx := fs.NewTx("/staging")
+			tx.CleanDir("/live")
+			tx.MoveAll("/release", "/live")
+			tx.WriteString("/live/config.txt", "new config")
+			tx.Delete("/release")
+			errors.AssertNil(t, tx.Commit())
+
+			assertFileContent(t, fs, "/live/config.txt", "new config")
+			assertFileContent(t, fs, "/live/sub/data.txt", "new data")
+			assertFileContent(t, fs, "/live/new.txt", "new file")
+			assertNotExists(t, fs, "/release")
+			assertStagingEmpty(t, fs)
+		})
+
+		t.Run("TestTxMoveReplace", func(t *testing.T) {
+			errors.AssertNil(t, fs.WriteString("/replacement.txt", "replacement"))
+			tx := fs.NewTx("/staging")
+			tx.Move("/replacement.txt", "/live/config.txt")
+			errors.AssertNil(t, tx.Commit())
+			assertFileContent(t, fs, "/live/config.txt", "replacement")
+			assertNotExists(t, fs, "/replacement.txt")
+		})
+
+		t.Run("TestTxRollback", func(t *testing.T) {
+			tx := fs.NewTx("/staging")
+			tx.Delete("/live")
+			errors.AssertNil(t, tx.Rollback())
+			errors.Assert(t, ErrTxFinished, tx.Commit())
+			assertIsDir(t, fs, "/live")
+		})
+
+		t.Run("TestTxStagingConflict", func(t *testing.T) {
+			errors.AssertNil(t, fs.CreateDirectory("/live/staging"))
+			tx := fs.NewTx("/live/staging")
+			tx.CleanDir("/live")
+			errors.Assert(t, ErrTxStagingConflict, tx.Commit())
+
+			tx = fs.NewTx("/live/staging")
+			tx.Move("/release", "/live")
+			errors.Assert(t, ErrTxStagingConflict, tx.Commit())
+
+			tx = fs.NewTx("/live/staging")
+			tx.Delete("/live/staging")
+			errors.Assert(t, ErrTxStagingConflict, tx.Commit())
+
+			assertIsDir(t, fs, "/live/staging")
+			errors.AssertNil(t, fs.DeleteDirectory("/live/staging", false))
+		})
+		return nil
+	}))
+}
+
+func TestRecoverTx(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		driver := &failingTxDriver{LocalDriver: &LocalDriver{Root: tmpDir}}
+		fs := NewWithDriver(driver)
+		errors.AssertNil(t, fs.CreateDirectory("/staging"))
+		errors.AssertNil(t, fs.CreateDirectory("/live"))
+		errors.AssertNil(t, fs.WriteString("/live/config.txt", "old config"))
+
+		t.Run("TestRecoverFailedRollback", func(t *testing.T) {
+			// fail restoring the staged file during rollback
+			driver.failRestore = true
+			tx := fs.NewTx("/staging")
+			tx.WriteString("/live/config.txt", "new config")
+			tx.WriteString("/live/created.txt", "created")
+			tx.Move("/nonexisting.txt", "/live/fail.txt")
+			errors.Assert(t, ErrRollbackFailed, tx.Commit())
+			driver.failRestore = false
+			assertNotExists(t, fs, "/live/config.txt")
+
+			errors.AssertNil(t, fs.RecoverTx("/staging"))
+			assertFileContent(t, fs, "/live/config.txt", "old config")
+			assertNotExists(t, fs, "/live/created.txt")
+			assertStagingEmpty(t, fs)
+		})
+
+		t.Run("TestRecoverCommitted", func(t *testing.T) {
+			tx := fs.NewTx("/staging")
+			tx.Delete("/live/config.txt")
+			errors.AssertNil(t, tx.Commit())
+			errors.AssertNil(t, fs.RecoverTx("/staging"))
+			assertNotExists(t, fs, "/live/config.txt")
+		})
+
+		t.Run("TestCommitEntryFailure", func(t *testing.T) {
+			errors.AssertNil(t, fs.WriteString("/live/config.txt", "old config"))
+			// fail writing the commit entry after the deletion has been staged
+			driver.failCommit = true
+			tx := fs.NewTx("/staging")
+			tx.Delete("/live/config.txt")
+			errors.Assert(t, Err, tx.Commit())
+			driver.failCommit = false
+			assertFileContent(t, fs, "/live/config.txt", "old config")
+			assertStagingEmpty(t, fs)
+		})
+
+		t.Run("TestCommitCleanupFailure", func(t *testing.T) {
+			driver.failCleanup = true
+			tx := fs.NewTx("/staging")
+			tx.WriteString("/live/config.txt", "new config")
+			errors.AssertNil(t, tx.Commit())
+			driver.failCleanup = false
+
+			errors.AssertNil(t, fs.RecoverTx("/staging"))
+			assertFileContent(t, fs, "/live/config.txt", "new config")
+			assertStagingEmpty(t, fs)
+		})
+
+		t.Run("TestRecoverMissingStagingDir", func(t *testing.T) {
+			errors.AssertNil(t, fs.RecoverTx("/nonexisting"))
+		})
+		return nil
+	}))
+}
+
+func assertStagingEmpty(t *testing.T, fs *FileSystem) bool {
+	files, err := fs.ReadDir("/staging")
+	if errors.AssertNil(t, err) {
+		return assert.Len(t, files, 0)
+	}
+	return false
+}
+
+// failingTxDriver fails selected steps of transactions in the staging directory "/staging".
+type failingTxDriver struct {
+	*LocalDriver
+	// failRestore fails moving staged elements back to their original location.
+	failRestore bool
+	// failCommit fails writing the commit entry to the journal.
+	failCommit bool
+	// failCleanup fails deleting transaction directories.
+	failCleanup bool
+}
+
+func isTxPath(p string) bool {
+	return strings.HasPrefix(p, "/staging/"+txDirPrefix)
+}
+
+func (d *failingTxDriver) OpenFile(p string, flags OpenFlags) (File, errors.Error) {
+	f, err := d.LocalDriver.OpenFile(p, flags)
+	if err != nil || !d.failCommit || !isTxPath(p) || !strings.HasSuffix(p, "/"+txJournalName) {
+		return f, err
+	}
+	return &failingCommitFile{f, p}, nil
+}
+
+func (d *failingTxDriver) MoveFile(src, dst string) errors.Error {
+	if d.failRestore && isTxPath(src) {
+		return ErrAccessDenied.Args(src).Make()
+	}
+	return d.LocalDriver.MoveFile(src, dst)
+}
+
+func (d *failingTxDriver) DeleteDirectory(p string, recursive bool) errors.Error {
+	if d.failCleanup && isTxPath(p) {
+		return ErrAccessDenied.Args(p).Make()
+	}
+	return d.LocalDriver.DeleteDirectory(p, recursive)
+}
+
+type failingCommitFile struct {
+	File
+	path string
+}
+
+func (f *failingCommitFile) Write(p []byte) (int, error) {
+	if bytes.Contains(p, []byte(`"op":"commit"`)) {
+		return 0, ErrAccessDenied.Args(f.path).Make()
+	}
+	return f.File.Write(p)
+}
@@ -0,0 +1,222 @@
+package fs
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sbreitf1/fs/path"
+
+	"github.com/sbreitf1/errors"
+)
+
+const (
+	// DefaultLargestFilesCount denotes the number of largest files returned by DiskUsage.
+	DefaultLargestFilesCount = 10
+)
+
+// DiskUsageStats contains statistics of a directory tree.
+type DiskUsageStats struct {
+	// TotalBytes is the sum of all file sizes.
+	TotalBytes int64
+	// FileCount is the number of files found recursively.
+	FileCount int
+	// DirCount is the number of sub-directories found recursively. The walked directory itself is not counted.
+	DirCount int
+	// LargestFiles contains the largest files ordered by size descending.
+	LargestFiles []FileUsage
+	// Extensions contains the usage grouped by lower case file extension including the dot character. Files without extension are grouped using an empty string.
+	Extensions map[string]*ExtensionUsage
+}
+
+// FileUsage describes the size of a single file.
+type FileUsage struct {
+	Path string
+	Size int64
+}
+
+// ExtensionUsage contains the statistics for all files with the same extension.
+type ExtensionUsage struct {
+	TotalBytes int64
+	FileCount  int
+}
+
+// DiskUsageOptions can be used to specify the behavior of DiskUsage.
+type DiskUsageOptions struct {
+	// LargestFilesCount denotes the number of largest files to return. Defaults to DefaultLargestFilesCount, set to a negative value to omit the list.
+	LargestFilesCount int
+	// Parallelism denotes the maximum number of directories that are read concurrently. Values below 2 use a sequential Walk.
+	Parallelism int
+}
+
+// DiskUsage recursively computes the number of files and directories and the total size of a directory.
+func (fs *FileSystem) DiskUsage(dir string, options *DiskUsageOptions) (*DiskUsageStats, errors.Error) {
+	if !fs.canNavigate {
+		return nil, ErrNotSupported.Args("DiskUsage").Make()
+	}
+
+	if options == nil {
+		options = &DiskUsageOptions{}
+	}
+
+	largestFilesCount := options.LargestFilesCount
+	if largestFilesCount == 0 {
+		largestFilesCount = DefaultLargestFilesCount
+	} else if largestFilesCount < 0 {
+		largestFilesCount = 0
+	}
+
+	usage := &DiskUsageStats{LargestFiles: make([]FileUsage, 0), Extensions: make(map[string]*ExtensionUsage)}
+	collector := &usageCollector{usage: usage, largestFilesCount: largestFilesCount}
+
+	var err errors.Error
+	if options.Parallelism > 1 {
+		err = fs.diskUsageParallel(dir, collector, options.Parallelism)
+	} else {
+		err = fs.Walk(dir, func(dir string, f FileInfo, isRoot bool) errors.Error {
+			collector.add(dir, f)
+			return nil
+		}, nil, nil, &WalkOptions{ListBatchSize: DefaultListBatchSize})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+func (fs *FileSystem) diskUsageParallel(dir string, collector *usageCollector, parallelism int) errors.Error {
+	// fail early for missing or inaccessible directories
+	if _, err := fs.Stat(dir); err != nil {
+		return err
+	}
+
+	queue := newDirQueue(dir)
+	var wg sync.WaitGroup
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				dir, ok := queue.next()
+				if !ok {
+					return
+				}
+
+				subDirs := make([]string, 0)
+				err := fs.listDirBatches(dir, func(files []FileInfo) {
+					for _, f := range files {
+						collector.add(dir, f)
+						if f.IsDir() {
+							subDirs = append(subDirs, path.Join(dir, f.Name()))
+						}
+					}
+				})
+				queue.done(subDirs, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return queue.err
+}
+
+// dirQueue distributes directories to a fixed number of workers. Sub-directories found by workers are appended to the queue.
+type dirQueue struct {
+	mutex sync.Mutex
+	cond  *sync.Cond
+	dirs  []string
+	// pending denotes the number of queued directories and directories that are currently processed.
+	pending int
+	err     errors.Error
+}
+
+func newDirQueue(dir string) *dirQueue {
+	q := &dirQueue{dirs: []string{dir}, pending: 1}
+	q.cond = sync.NewCond(&q.mutex)
+	return q
+}
+
+// next returns the next directory to process. It blocks until a directory is available and returns false when all directories have been processed or an error occured.
+func (q *dirQueue) next() (string, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for len(q.dirs) == 0 && q.pending > 0 && q.err == nil {
+		q.cond.Wait()
+	}
+	if q.err != nil || len(q.dirs) == 0 {
+		return "", false
+	}
+
+	// process depth-first to keep the queue small
+	dir := q.dirs[len(q.dirs)-1]
+	q.dirs = q.dirs[:len(q.dirs)-1]
+	return dir, true
+}
+
+// done marks a directory returned by next as processed and queues its sub-directories.
+func (q *dirQueue) done(subDirs []string, err errors.Error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if err != nil && q.err == nil {
+		q.err = err
+	}
+	q.dirs = append(q.dirs, subDirs...)
+	q.pending += len(subDirs) - 1
+	q.cond.Broadcast()
+}
+
+type usageCollector struct {
+	mutex             sync.Mutex
+	usage             *DiskUsageStats
+	largestFilesCount int
+}
+
+func (c *usageCollector) add(dir string, f FileInfo) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if f.IsDir() {
+		c.usage.DirCount++
+		return
+	}
+
+	c.usage.FileCount++
+	c.usage.TotalBytes += f.Size()
+
+	ext := strings.ToLower(path.Ext(f.Name()))
+	extUsage, ok := c.usage.Extensions[ext]
+	if !ok {
+		extUsage = &ExtensionUsage{}
+		c.usage.Extensions[ext] = extUsage
+	}
+	extUsage.FileCount++
+	extUsage.TotalBytes += f.Size()
+
+	if c.largestFilesCount > 0 {
+		file := FileUsage{Path: path.Join(dir, f.Name()), Size: f.Size()}
+		files := c.usage.LargestFiles
+		if len(files) < c.largestFilesCount || isLargerFile(file, files[len(files)-1]) {
+			index := sort.Search(len(files), func(i int) bool {
+				return isLargerFile(file, files[i])
+			})
+			files = append(files, FileUsage{})
+			copy(files[index+1:], files[index:])
+			files[index] = file
+			if len(files) > c.largestFilesCount {
+				files = files[:c.largestFilesCount]
+			}
+			c.usage.LargestFiles = files
+		}
+	}
+}
+
+func isLargerFile(f1, f2 FileUsage) bool {
+	if f1.Size != f2.Size {
+		return f1.Size > f2.Size
+	}
+	// use path as tie breaker to be independent of visit order
+	return f1.Path < f2.Path
+}
@@ -0,0 +1,103 @@
+package fs
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskUsage(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		fs := NewWithDriver(&LocalDriver{Root: tmpDir})
+		errors.AssertNil(t, fs.CreateDirectory("/foo/bar"))
+		errors.AssertNil(t, fs.CreateDirectory("/foo/empty"))
+		errors.AssertNil(t, fs.WriteString("/foo/a.txt", "12345"))
+		errors.AssertNil(t, fs.WriteString("/foo/bar/b.TXT", "1234567890"))
+		errors.AssertNil(t, fs.WriteString("/foo/bar/c.go", "123"))
+		errors.AssertNil(t, fs.WriteString("/foo/bar/README", "1"))
+
+		t.Run("TestSequential", func(t *testing.T) {
+			usage, err := fs.DiskUsage("/foo", &DiskUsageOptions{LargestFilesCount: 2})
+			if errors.AssertNil(t, err) {
+				assertDiskUsage(t, usage)
+			}
+		})
+
+		t.Run("TestParallel", func(t *testing.T) {
+			usage, err := fs.DiskUsage("/foo", &DiskUsageOptions{LargestFilesCount: 2, Parallelism: 4})
+			if errors.AssertNil(t, err) {
+				assertDiskUsage(t, usage)
+			}
+		})
+
+		t.Run("TestParallelError", func(t *testing.T) {
+			driver := &failingReadDirDriver{LocalDriver: &LocalDriver{Root: tmpDir}}
+			driver.setFailing("/foo/bar")
+			_, err := NewWithDriver(driver).DiskUsage("/foo", &DiskUsageOptions{Parallelism: 4})
+			errors.Assert(t, ErrAccessDenied, err)
+		})
+
+		t.Run("TestNoLargestFiles", func(t *testing.T) {
+			usage, err := fs.DiskUsage("/foo", &DiskUsageOptions{LargestFilesCount: -1})
+			if errors.AssertNil(t, err) {
+				assert.Len(t, usage.LargestFiles, 0)
+			}
+		})
+
+		t.Run("TestNonExistent", func(t *testing.T) {
+			_, err := fs.DiskUsage("/nonexistingdir", nil)
+			errors.Assert(t, ErrNotExists, err)
+			_, err = fs.DiskUsage("/nonexistingdir", &DiskUsageOptions{Parallelism: 4})
+			errors.Assert(t, ErrNotExists, err)
+		})
+		return nil
+	}))
+}
+
+func assertDiskUsage(t *testing.T, usage *DiskUsageStats) {
+	assert.Equal(t, int64(19), usage.TotalBytes)
+	assert.Equal(t, 4, usage.FileCount)
+	assert.Equal(t, 2, usage.DirCount)
+	assert.Equal(t, []FileUsage{{Path: "/foo/bar/b.TXT", Size: 10}, {Path: "/foo/a.txt", Size: 5}}, usage.LargestFiles)
+	assert.Equal(t, map[string]*ExtensionUsage{
+		".txt": {TotalBytes: 15, FileCount: 2},
+		".go":  {TotalBytes: 3, FileCount: 1},
+		"":     {TotalBytes: 1, FileCount: 1},
+	}, usage.Extensions)
+}
+
+// failingReadDirDriver denies listing a single directory.
+type failingReadDirDriver struct {
+	*LocalDriver
+	mutex sync.Mutex
+	path  string
+}
+
+// setFailing denies listing path from now on. Pass an empty path to allow all directories again.
+func (d *failingReadDirDriver) setFailing(path string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.path = path
+}
+
+func (d *failingReadDirDriver) isFailing(path string) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return len(d.path) > 0 && path == d.path
+}
+
+func (d *failingReadDirDriver) ReadDir(path string) ([]FileInfo, errors.Error) {
+	if d.isFailing(path) {
+		return nil, ErrAccessDenied.Args(path).Make()
+	}
+	return d.LocalDriver.ReadDir(path)
+}
+
+func (d *failingReadDirDriver) ListDir(path string) (DirCursor, errors.Error) {
+	if d.isFailing(path) {
+		return nil, ErrAccessDenied.Args(path).Make()
+	}
+	return d.LocalDriver.ListDir(path)
+}
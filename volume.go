@@ -0,0 +1,78 @@
+package fs
+
+import (
+	"github.com/sbreitf1/fs/path"
+
+	"github.com/sbreitf1/errors"
+)
+
+// VolumeStats contains information about the volume a path is located on.
+type VolumeStats struct {
+	// TotalBytes is the total size of the volume.
+	TotalBytes uint64
+	// FreeBytes is the number of unused bytes on the volume including space reserved for privileged users.
+	FreeBytes uint64
+	// AvailableBytes is the number of bytes that can be used by the current user.
+	AvailableBytes uint64
+	// ReadOnly is true for volumes that are mounted read-only.
+	ReadOnly bool
+	// FileSystemType contains the name of the file system like "ext4" if known.
+	FileSystemType string
+}
+
+// VolumeInfoDriver describes optional functionality to retrieve information about the volume a path is located on. Wrapping drivers return ErrNotSupported if the wrapped driver cannot retrieve volume information.
+type VolumeInfoDriver interface {
+	VolumeInfo(path string) (VolumeStats, errors.Error)
+}
+
+// CanVolumeInfo returns true when the driver implements VolumeInfoDriver. VolumeInfo may still return ErrNotSupported for wrapping drivers.
+func (fs *FileSystem) CanVolumeInfo() bool {
+	_, ok := fs.navDriver.(VolumeInfoDriver)
+	return ok
+}
+
+// VolumeInfo returns information about the volume the given path is located on.
+func (fs *FileSystem) VolumeInfo(path string) (VolumeStats, errors.Error) {
+	driver, ok := fs.navDriver.(VolumeInfoDriver)
+	if !ok {
+		return VolumeStats{}, ErrNotSupported.Args("VolumeInfo").Make()
+	}
+
+	return driver.VolumeInfo(path)
+}
+
+// EnsureFreeSpace returns ErrInsufficientSpace when the volume of the given path does not offer the required number of bytes. Non-existent paths are checked using their nearest existing parent directory. Nothing is checked for drivers that do not support VolumeInfoDriver.
+func (fs *FileSystem) EnsureFreeSpace(p string, requiredBytes uint64) errors.Error {
+	if !fs.CanVolumeInfo() {
+		return nil
+	}
+
+	for {
+		exists, err := fs.Exists(p)
+		if err != nil {
+			return err
+		}
+		if exists {
+			break
+		}
+
+		parent := path.Dir(p)
+		if parent == p {
+			break
+		}
+		p = parent
+	}
+
+	info, err := fs.VolumeInfo(p)
+	if err != nil {
+		if errors.InstanceOf(err, ErrNotSupported) {
+			return nil
+		}
+		return err
+	}
+
+	if info.AvailableBytes < requiredBytes {
+		return ErrInsufficientSpace.Args(requiredBytes, info.AvailableBytes).Make()
+	}
+	return nil
+}
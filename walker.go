@@ -0,0 +1,197 @@
+package fs
+
+import (
+	"github.com/sbreitf1/fs/path"
+
+	"github.com/sbreitf1/errors"
+)
+
+// WalkEntry describes a single file or directory returned by a Walker.
+type WalkEntry struct {
+	// Dir is the directory containing the entry. For the root entry this is the walked directory itself.
+	Dir string
+	// Info contains the file info of the entry.
+	Info FileInfo
+	// IsRoot is true for the walked directory itself.
+	IsRoot bool
+}
+
+// Path returns the full path of the entry.
+func (e WalkEntry) Path() string {
+	if e.IsRoot {
+		return e.Dir
+	}
+	return path.Join(e.Dir, e.Info.Name())
+}
+
+type walkerFrame struct {
+	dir   string
+	files []FileInfo
+	index int
+	// cursor is used to read the next batch of files if ListBatchSize is set.
+	cursor DirCursor
+	// listErr is returned after all files of the current batch have been visited.
+	listErr errors.Error
+}
+
+// Walker iterates over all files and directories contained in a directory recursively. It yields the same entries in the same order as the visit handler of Walk, including batched listings for ListBatchSize.
+//
+// Call Next to advance to the next entry and Entry to retrieve it. Iteration can be aborted at any time by not calling Next anymore. Call Close in this case to release directories that are listed in batches.
+type Walker struct {
+	fs      *FileSystem
+	root    string
+	options WalkOptions
+	started bool
+	done    bool
+	stack   []*walkerFrame
+	entry   WalkEntry
+	descend bool
+	err     errors.Error
+}
+
+// NewWalker returns a new iterator for all files and directories contained in dir recursively.
+func (fs *FileSystem) NewWalker(dir string, options *WalkOptions) *Walker {
+	w := &Walker{fs: fs, root: dir}
+	if options != nil {
+		w.options = *options
+	}
+	return w
+}
+
+// Next advances to the next entry and returns false when all entries have been visited or an error occured.
+func (w *Walker) Next() bool {
+	if w.done {
+		return false
+	}
+
+	if !w.started {
+		w.started = true
+		if !w.fs.canNavigate {
+			return w.fail(ErrNotSupported.Args("Walker").Make())
+		}
+
+		fi, err := w.fs.Stat(w.root)
+		if err != nil {
+			return w.fail(err)
+		}
+
+		w.entry = WalkEntry{Dir: w.root, Info: fi, IsRoot: true}
+		w.descend = true
+		if w.options.VisitRootDir {
+			return true
+		}
+	}
+
+	if w.descend {
+		w.descend = false
+		if w.entry.IsRoot || (!w.options.SkipSubDirs && w.entry.Info.IsDir()) {
+			if err := w.push(w.entry.Path()); err != nil {
+				return w.fail(err)
+			}
+		}
+	}
+
+	for len(w.stack) > 0 {
+		frame := w.stack[len(w.stack)-1]
+		if frame.index >= len(frame.files) {
+			if err := w.nextBatch(frame); err != nil {
+				return w.fail(err)
+			}
+			if frame.index >= len(frame.files) {
+				w.pop()
+				continue
+			}
+		}
+
+		f := frame.files[frame.index]
+		frame.index++
+		w.entry = WalkEntry{Dir: frame.dir, Info: f}
+		w.descend = f.IsDir()
+		return true
+	}
+
+	w.done = true
+	return false
+}
+
+func (w *Walker) push(dir string) errors.Error {
+	if w.options.VisitOrder == nil && w.options.ListBatchSize > 0 {
+		cursor, err := w.fs.ListDir(dir)
+		if err != nil {
+			return err
+		}
+		w.stack = append(w.stack, &walkerFrame{dir: dir, cursor: cursor})
+		return nil
+	}
+
+	files, err := w.fs.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	if w.options.VisitOrder != nil {
+		Sort(files, w.options.VisitOrder)
+	}
+
+	w.stack = append(w.stack, &walkerFrame{dir: dir, files: files})
+	return nil
+}
+
+// nextBatch reads the next files of a directory that is listed in batches. Nothing is read for completely read directories.
+func (w *Walker) nextBatch(frame *walkerFrame) errors.Error {
+	if frame.cursor == nil {
+		return nil
+	}
+	if frame.listErr != nil {
+		return frame.listErr
+	}
+
+	files, err := frame.cursor.Next(w.options.ListBatchSize)
+	frame.files = files
+	frame.index = 0
+	frame.listErr = err
+	if len(files) == 0 {
+		return err
+	}
+	return nil
+}
+
+func (w *Walker) pop() {
+	frame := w.stack[len(w.stack)-1]
+	if frame.cursor != nil {
+		frame.cursor.Close()
+	}
+	w.stack = w.stack[:len(w.stack)-1]
+}
+
+func (w *Walker) fail(err errors.Error) bool {
+	w.err = err
+	w.Close()
+	return false
+}
+
+// Close ends the iteration and releases all directories that are currently listed. Calling Close multiple times has no effect.
+func (w *Walker) Close() {
+	w.done = true
+	for len(w.stack) > 0 {
+		w.pop()
+	}
+}
+
+// Entry returns the current entry. It is only valid after Next returned true.
+func (w *Walker) Entry() WalkEntry {
+	return w.entry
+}
+
+// SkipDir prevents the Walker from entering the directory returned by the last call to Next. Skipping the root entry ends the iteration.
+func (w *Walker) SkipDir() {
+	w.descend = false
+	if w.entry.IsRoot {
+		w.done = true
+	}
+}
+
+// Err returns the error that caused Next to return false. It returns nil when all entries have been visited successfully.
+func (w *Walker) Err() errors.Error {
+	return w.err
+}
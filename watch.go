@@ -0,0 +1,297 @@
+package fs
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sbreitf1/fs/path"
+
+	"github.com/sbreitf1/errors"
+)
+
+const (
+	// DefaultPollInterval denotes the interval used by polling watchers to compare the watched tree.
+	DefaultPollInterval = time.Second
+	// watchEventBufferSize denotes the number of events that can be queued before watchers block.
+	watchEventBufferSize = 100
+)
+
+var (
+	// ErrWatchOverflow occurs when a watcher dropped events because they could not be processed fast enough.
+	ErrWatchOverflow = errors.New("Events have been dropped because the event queue overflowed")
+)
+
+// WatchEventType denotes the kind of change reported by a watcher.
+type WatchEventType int
+
+const (
+	// EventCreate denotes a new file or directory.
+	EventCreate WatchEventType = iota
+	// EventModify denotes a file whose content has been changed.
+	EventModify
+	// EventDelete denotes a removed file or directory.
+	EventDelete
+	// EventRename denotes a file or directory that has been moved from OldPath to Path. Polling watchers report renames as delete and create events.
+	EventRename
+)
+
+// String returns a human readable representation of the event type.
+func (t WatchEventType) String() string {
+	switch t {
+	case EventCreate:
+		return "create"
+	case EventModify:
+		return "modify"
+	case EventDelete:
+		return "delete"
+	case EventRename:
+		return "rename"
+	default:
+		return "unknown"
+	}
+}
+
+// WatchEvent describes a single change of a watched file or directory.
+type WatchEvent struct {
+	Type WatchEventType
+	Path string
+	// OldPath contains the previous path for EventRename.
+	OldPath string
+	IsDir   bool
+}
+
+// Watcher delivers change events of a watched path. It must be closed after usage.
+type Watcher interface {
+	// Events returns the channel that receives all changes. It is closed when the watcher is closed.
+	Events() <-chan WatchEvent
+	// Errors returns the channel that receives errors occuring while watching. It is closed when the watcher is closed. Errors are dropped while a previous error has not been received, so that an unread error channel does not stall the delivery of events.
+	Errors() <-chan errors.Error
+	Close() errors.Error
+}
+
+// WatchFileSystemDriver describes optional functionality for drivers that are notified about changes natively.
+//
+// Without native notifications, Watch falls back to polling. The polling watcher only compares snapshots of the tree, so it never reports ErrWatchOverflow, reports renames as deletion and creation, and does not report modifications of directories.
+type WatchFileSystemDriver interface {
+	Watch(path string, recursive bool) (Watcher, errors.Error)
+}
+
+// WatchOptions can be used to specify the behavior of Watch.
+type WatchOptions struct {
+	// ForcePolling causes Watch to compare the watched tree periodically even if the driver implements WatchFileSystemDriver.
+	ForcePolling bool
+	// PollInterval denotes the interval of polling watchers. Defaults to DefaultPollInterval.
+	PollInterval time.Duration
+}
+
+// Watch returns a watcher that reports changes of a file or the content of a directory. Set recursive to true to also watch all sub-directories. Native notifications are used for drivers that implement WatchFileSystemDriver, otherwise the tree is polled.
+func (fs *FileSystem) Watch(path string, recursive bool) (Watcher, errors.Error) {
+	return fs.WatchWithOptions(path, recursive, nil)
+}
+
+// WatchWithOptions returns a watcher that reports changes of a file or the content of a directory using the given options.
+func (fs *FileSystem) WatchWithOptions(path string, recursive bool, options *WatchOptions) (Watcher, errors.Error) {
+	if !fs.canNavigate {
+		return nil, ErrNotSupported.Args("Watch").Make()
+	}
+
+	if options == nil {
+		options = &WatchOptions{}
+	}
+
+	if driver, ok := fs.navDriver.(WatchFileSystemDriver); ok && !options.ForcePolling {
+		watcher, err := driver.Watch(path, recursive)
+		if err == nil || !errors.InstanceOf(err, ErrNotSupported) {
+			return watcher, err
+		}
+	}
+
+	interval := options.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	return fs.newPollWatcher(path, recursive, interval)
+}
+
+type pollWatcher struct {
+	fs        *FileSystem
+	path      string
+	recursive bool
+	state     map[string]pollState
+	events    chan WatchEvent
+	errs      chan errors.Error
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+type pollState struct {
+	isDir   bool
+	size    int64
+	modTime time.Time
+}
+
+func (fs *FileSystem) newPollWatcher(path string, recursive bool, interval time.Duration) (*pollWatcher, errors.Error) {
+	// fail early for missing paths, later removals are reported as delete events
+	if _, err := fs.Stat(path); err != nil {
+		return nil, err
+	}
+
+	w := &pollWatcher{
+		fs:        fs,
+		path:      path,
+		recursive: recursive,
+		events:    make(chan WatchEvent, watchEventBufferSize),
+		errs:      make(chan errors.Error, 1),
+		done:      make(chan struct{}),
+	}
+
+	state, err := w.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	w.state = state
+
+	w.wg.Add(1)
+	go w.run(interval)
+	return w, nil
+}
+
+func (w *pollWatcher) Events() <-chan WatchEvent {
+	return w.events
+}
+
+func (w *pollWatcher) Errors() <-chan errors.Error {
+	return w.errs
+}
+
+func (w *pollWatcher) Close() errors.Error {
+	w.closeOnce.Do(func() {
+		close(w.done)
+		w.wg.Wait()
+		close(w.events)
+		close(w.errs)
+	})
+	return nil
+}
+
+func (w *pollWatcher) run(interval time.Duration) {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+
+		state, err := w.snapshot()
+		if err != nil {
+			if !w.sendError(err) {
+				return
+			}
+			continue
+		}
+
+		for _, event := range diffPollStates(w.state, state) {
+			if !w.sendEvent(event) {
+				return
+			}
+		}
+		w.state = state
+	}
+}
+
+func (w *pollWatcher) sendEvent(event WatchEvent) bool {
+	select {
+	case w.events <- event:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+func (w *pollWatcher) sendError(err errors.Error) bool {
+	return sendWatchError(w.errs, w.done, err)
+}
+
+// sendWatchError delivers an error without blocking. The error is dropped if a previous error has not been received yet. Returns false if the watcher has been closed.
+func sendWatchError(errs chan<- errors.Error, done <-chan struct{}, err errors.Error) bool {
+	select {
+	case <-done:
+		return false
+	default:
+	}
+
+	select {
+	case errs <- err:
+	default:
+	}
+	return true
+}
+
+// snapshot returns the state of all watched elements. A missing watched path results in an empty snapshot.
+func (w *pollWatcher) snapshot() (map[string]pollState, errors.Error) {
+	state := make(map[string]pollState)
+
+	fi, err := w.fs.Stat(w.path)
+	if err != nil {
+		if errors.InstanceOf(err, ErrNotExists) {
+			return state, nil
+		}
+		return nil, err
+	}
+	if !fi.IsDir() {
+		state[w.path] = newPollState(fi)
+		return state, nil
+	}
+
+	err = w.fs.Walk(w.path, func(dir string, f FileInfo, isRoot bool) errors.Error {
+		if !isRoot {
+			state[path.Join(dir, f.Name())] = newPollState(f)
+		}
+		return nil
+	}, nil, nil, &WalkOptions{SkipSubDirs: !w.recursive, ListBatchSize: DefaultListBatchSize})
+	if err != nil {
+		if errors.InstanceOf(err, ErrNotExists) || errors.InstanceOf(err, ErrDirectoryNotExists) {
+			// removed while walking, changes are detected by the next snapshot
+			return w.state, nil
+		}
+		return nil, err
+	}
+	return state, nil
+}
+
+func newPollState(f FileInfo) pollState {
+	return pollState{isDir: f.IsDir(), size: f.Size(), modTime: modTime(f)}
+}
+
+// diffPollStates returns the events that transform oldState to newState ordered by path.
+func diffPollStates(oldState, newState map[string]pollState) []WatchEvent {
+	events := make([]WatchEvent, 0)
+	for p, oldFile := range oldState {
+		newFile, ok := newState[p]
+		if !ok {
+			events = append(events, WatchEvent{Type: EventDelete, Path: p, IsDir: oldFile.isDir})
+		} else if oldFile.isDir != newFile.isDir {
+			events = append(events, WatchEvent{Type: EventDelete, Path: p, IsDir: oldFile.isDir})
+			events = append(events, WatchEvent{Type: EventCreate, Path: p, IsDir: newFile.isDir})
+		} else if !newFile.isDir && (oldFile.size != newFile.size || !oldFile.modTime.Equal(newFile.modTime)) {
+			events = append(events, WatchEvent{Type: EventModify, Path: p})
+		}
+	}
+	for p, newFile := range newState {
+		if _, ok := oldState[p]; !ok {
+			events = append(events, WatchEvent{Type: EventCreate, Path: p, IsDir: newFile.isDir})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Path < events[j].Path
+	})
+	return events
+}
@@ -0,0 +1,142 @@
+package fs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPollWatcher(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		fs := NewWithDriver(&LocalDriver{Root: tmpDir})
+		errors.AssertNil(t, fs.CreateDirectory("/watched"))
+
+		_, err := fs.WatchWithOptions("/nonexisting", false, &WatchOptions{ForcePolling: true})
+		errors.Assert(t, ErrNotExists, err)
+
+		t.Run("TestRecursive", func(t *testing.T) {
+			w, err := fs.WatchWithOptions("/watched", true, &WatchOptions{ForcePolling: true, PollInterval: 10 * time.Millisecond})
+			errors.AssertNil(t, err)
+			defer w.Close()
+
+			errors.AssertNil(t, fs.WriteString("/watched/test.txt", "foo"))
+			waitForEvent(t, w, WatchEvent{Type: EventCreate, Path: "/watched/test.txt"})
+
+			errors.AssertNil(t, fs.WriteString("/watched/test.txt", "foo bar"))
+			waitForEvent(t, w, WatchEvent{Type: EventModify, Path: "/watched/test.txt"})
+
+			errors.AssertNil(t, fs.CreateDirectory("/watched/sub"))
+			errors.AssertNil(t, fs.WriteString("/watched/sub/blub.txt", "bar"))
+			waitForEvent(t, w, WatchEvent{Type: EventCreate, Path: "/watched/sub", IsDir: true})
+			waitForEvent(t, w, WatchEvent{Type: EventCreate, Path: "/watched/sub/blub.txt"})
+
+			errors.AssertNil(t, fs.DeleteDirectory("/watched/sub", true))
+			waitForEvent(t, w, WatchEvent{Type: EventDelete, Path: "/watched/sub", IsDir: true})
+			waitForEvent(t, w, WatchEvent{Type: EventDelete, Path: "/watched/sub/blub.txt"})
+
+			errors.AssertNil(t, fs.DeleteFile("/watched/test.txt"))
+			waitForEvent(t, w, WatchEvent{Type: EventDelete, Path: "/watched/test.txt"})
+		})
+
+		t.Run("TestNonRecursive", func(t *testing.T) {
+			errors.AssertNil(t, fs.CreateDirectory("/watched/sub"))
+			w, err := fs.WatchWithOptions("/watched", false, &WatchOptions{ForcePolling: true, PollInterval: 10 * time.Millisecond})
+			errors.AssertNil(t, err)
+			defer w.Close()
+
+			errors.AssertNil(t, fs.WriteString("/watched/sub/ignored.txt", "foo"))
+			errors.AssertNil(t, fs.WriteString("/watched/test.txt", "foo"))
+			// events are delivered ordered by path, so the ignored file would be reported first
+			waitForEvent(t, w, WatchEvent{Type: EventCreate, Path: "/watched/test.txt"})
+		})
+
+		t.Run("TestUnreadErrors", func(t *testing.T) {
+			driver := &failingReadDirDriver{LocalDriver: &LocalDriver{Root: tmpDir}}
+			fs := NewWithDriver(driver)
+			w, err := fs.WatchWithOptions("/watched", false, &WatchOptions{ForcePolling: true, PollInterval: 10 * time.Millisecond})
+			errors.AssertNil(t, err)
+			defer w.Close()
+
+			// multiple failed polls must not block the watcher
+			driver.setFailing("/watched")
+			time.Sleep(50 * time.Millisecond)
+			driver.setFailing("")
+
+			errors.AssertNil(t, fs.WriteString("/watched/unblocked.txt", "foo"))
+			select {
+			case event := <-w.Events():
+				assert.Equal(t, WatchEvent{Type: EventCreate, Path: "/watched/unblocked.txt"}, event)
+			case <-time.After(5 * time.Second):
+				assert.Fail(t, "Timeout while waiting for event")
+			}
+			// only the first error is kept
+			errors.Assert(t, ErrAccessDenied, <-w.Errors())
+			select {
+			case err := <-w.Errors():
+				assert.Nil(t, err)
+			default:
+			}
+		})
+
+		t.Run("TestClose", func(t *testing.T) {
+			w, err := fs.WatchWithOptions("/watched", false, &WatchOptions{ForcePolling: true, PollInterval: 10 * time.Millisecond})
+			errors.AssertNil(t, err)
+			errors.AssertNil(t, w.Close())
+			errors.AssertNil(t, w.Close())
+			_, ok := <-w.Events()
+			assert.False(t, ok)
+		})
+		return nil
+	}))
+}
+
+func TestDiffPollStates(t *testing.T) {
+	modTime := time.Now()
+	oldState := map[string]pollState{
+		"/a":     {isDir: true},
+		"/a/b":   {size: 1, modTime: modTime},
+		"/c":     {size: 1, modTime: modTime},
+		"/d":     {size: 1, modTime: modTime},
+		"/e":     {size: 1, modTime: modTime},
+		"/f/g.h": {size: 1, modTime: modTime},
+	}
+	newState := map[string]pollState{
+		"/a":     {isDir: true},
+		"/a/b":   {size: 1, modTime: modTime},
+		"/c":     {size: 2, modTime: modTime},
+		"/d":     {size: 1, modTime: modTime.Add(time.Second)},
+		"/e":     {isDir: true},
+		"/f":     {isDir: true},
+		"/f/g.h": {size: 1, modTime: modTime},
+	}
+
+	assert.Equal(t, []WatchEvent{
+		{Type: EventModify, Path: "/c"},
+		{Type: EventModify, Path: "/d"},
+		{Type: EventDelete, Path: "/e"},
+		{Type: EventCreate, Path: "/e", IsDir: true},
+		{Type: EventCreate, Path: "/f", IsDir: true},
+	}, diffPollStates(oldState, newState))
+}
+
+// waitForEvent reads events until the expected one is found.
+func waitForEvent(t *testing.T, w Watcher, expected WatchEvent) bool {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event, ok := <-w.Events():
+			if !ok {
+				return assert.Fail(t, "Watcher has been closed", "Expected event %v", expected)
+			}
+			if event == expected {
+				return true
+			}
+		case err := <-w.Errors():
+			return errors.AssertNil(t, err)
+		case <-timeout:
+			return assert.Fail(t, "Timeout while waiting for event", "Expected event %v", expected)
+		}
+	}
+}
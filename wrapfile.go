@@ -0,0 +1,58 @@
+package fs
+
+// fileUnwrapper is implemented by files returned from drivers that wrap another driver.
+type fileUnwrapper interface {
+	unwrap() File
+}
+
+type wrappedFile struct {
+	File
+	inner File
+}
+
+func (f wrappedFile) unwrap() File {
+	return f.inner
+}
+
+// wrapFile returns the file f of a wrapping driver that is based on the file inner of the wrapped driver. The returned file only implements Truncater and Syncer if inner does. The functions of f are used if available, otherwise the calls are passed to inner.
+func wrapFile(f, inner File) File {
+	w := wrappedFile{File: f, inner: inner}
+
+	truncater, canTruncate := inner.(Truncater)
+	if t, ok := f.(Truncater); ok {
+		truncater = t
+	}
+	syncer, canSync := inner.(Syncer)
+	if s, ok := f.(Syncer); ok {
+		syncer = s
+	}
+
+	switch {
+	case canTruncate && canSync:
+		return struct {
+			wrappedFile
+			Truncater
+			Syncer
+		}{w, truncater, syncer}
+	case canTruncate:
+		return struct {
+			wrappedFile
+			Truncater
+		}{w, truncater}
+	case canSync:
+		return struct {
+			wrappedFile
+			Syncer
+		}{w, syncer}
+	default:
+		return w
+	}
+}
+
+// unwrapFile returns the file of the wrapped driver for files returned by wrapFile, and f itself otherwise.
+func unwrapFile(f File) File {
+	if u, ok := f.(fileUnwrapper); ok {
+		return u.unwrap()
+	}
+	return f
+}
@@ -0,0 +1,64 @@
+package fs
+
+import (
+	"testing"
+
+	"github.com/sbreitf1/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapFile(t *testing.T) {
+	errors.AssertNil(t, WithTempDir("fs-test-", func(tmpDir string) errors.Error {
+		wrappers := map[string]func(ReadWriteFileSystemDriver) ReadWriteFileSystemDriver{
+			"Quota": func(driver ReadWriteFileSystemDriver) ReadWriteFileSystemDriver {
+				d, err := NewQuotaDriver(driver, 0, 0)
+				errors.AssertNil(t, err)
+				return d
+			},
+			"Fault": func(driver ReadWriteFileSystemDriver) ReadWriteFileSystemDriver {
+				return NewFaultDriver(driver, 0)
+			},
+			"Trace": func(driver ReadWriteFileSystemDriver) ReadWriteFileSystemDriver {
+				return NewTraceDriver(driver, &recordingSink{})
+			},
+			"Metrics": func(driver ReadWriteFileSystemDriver) ReadWriteFileSystemDriver {
+				return NewMetricsDriver(driver, nil)
+			},
+		}
+
+		openWrapped := func(t *testing.T, wrap func(ReadWriteFileSystemDriver) ReadWriteFileSystemDriver, driver ReadWriteFileSystemDriver) File {
+			f, err := wrap(driver).OpenFile("/test.txt", OpenWriteOnly.Create().Truncate())
+			errors.AssertNil(t, err)
+			return f
+		}
+
+		for name, wrap := range wrappers {
+			t.Run("Test"+name, func(t *testing.T) {
+				f := openWrapped(t, wrap, &LocalDriver{Root: tmpDir})
+				assert.Implements(t, (*Truncater)(nil), f)
+				assert.Implements(t, (*Syncer)(nil), f)
+				assert.NotEqual(t, f, unwrapFile(f))
+				assert.NoError(t, f.Close())
+
+				syncDriver := &syncCountingDriver{LocalDriver: &LocalDriver{Root: tmpDir}}
+				f = openWrapped(t, wrap, syncDriver)
+				_, canTruncate := f.(Truncater)
+				assert.False(t, canTruncate)
+				if syncer, ok := f.(Syncer); assert.True(t, ok) {
+					assert.NoError(t, syncer.Sync())
+					assert.Equal(t, 1, syncDriver.syncCount)
+				}
+				assert.NoError(t, f.Close())
+
+				f = openWrapped(t, wrap, &failingCloseDriver{&LocalDriver{Root: tmpDir}})
+				_, canTruncate = f.(Truncater)
+				assert.False(t, canTruncate)
+				_, canSync := f.(Syncer)
+				assert.False(t, canSync)
+				f.Close()
+			})
+		}
+
+		return nil
+	}))
+}